@@ -5,7 +5,9 @@ import (
 	"gateway-go/internal/config"
 	handler "gateway-go/internal/health"
 	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
 	"gateway-go/internal/router"
+	"gateway-go/internal/server"
 	"gateway-go/proxy"
 	"log"
 	"net/http"
@@ -15,52 +17,110 @@ import (
 	"time"
 )
 
+// 설정 파일 변경 감지 주기
+const configWatchInterval = 2 * time.Second
+
 func main() {
-	logConfigData, err := config.GetData(logger.LogConfigFileName)
-	if err != nil {
-		log.Fatal("Failed to read log config:", err)
-	}
-	logConfig, err := logger.ReadConfig(logConfigData)
-	if err != nil {
-		log.Fatal("Failed to parse log config:", err)
+	gw := &gateway{}
+	if err := gw.load(); err != nil {
+		log.Fatal(err)
 	}
-	close, err := logger.SetUp(logConfig)
-	if err != nil {
-		log.Fatal("Failed to initialize logger:", err)
-	}
-	defer close()
+	defer gw.close()
+
+	handler.SetUpstreams(gw.router)
+	newProxy := proxy.NewProxy(gw.router)
 
+	// HTTP 서버 설정 (server 섹션은 재시작 시에만 반영된다)
 	routerConfigData, err := config.GetData(router.RouterConfigName)
 	if err != nil {
-		log.Fatal("Failed to read router config:", err)
+		log.Fatal(err)
 	}
-	newRouter, err := router.NewRouter(routerConfigData)
+	serverConfig, err := server.ReadConfig(routerConfigData)
 	if err != nil {
-		logger.App.Error("Failed to initialize router", "error", err)
-		return
+		log.Fatal(err)
 	}
 
-	newProxy := proxy.NewProxy(newRouter)
-
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", handler.HealthHandler)
 	mux.Handle("/", &newProxy)
 
-	// HTTP 서버 설정
-	server := &http.Server{
-		Addr:    ":8080",
+	// metrics와 upstream 상태는 내부 주소를 담고 있어 admin listener에서만 노출한다
+	var adminServer *http.Server
+	if serverConfig.AdminAddress != "" {
+		adminMux := http.NewServeMux()
+		adminMux.HandleFunc("/health", handler.UpstreamHealthHandler)
+		adminMux.Handle("/metrics", metrics.Default)
+		adminServer = &http.Server{
+			Addr:    serverConfig.AdminAddress,
+			Handler: adminMux,
+		}
+	} else {
+		logger.App.Warn("admin_address is not set, /metrics and upstream health are disabled")
+	}
+	httpServer := &http.Server{
+		Addr:    serverConfig.Address,
 		Handler: mux,
 	}
+	if serverConfig.TLS != nil {
+		httpServer.TLSConfig, err = serverConfig.TLS.Build()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// 서버를 고루틴에서 실행
 	go func() {
-		logger.App.Info("Gateway server starting", "address", ":8080")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.App.Info("Gateway server starting", "address", serverConfig.Address, "tls", serverConfig.TLS != nil)
+		var err error
+		if httpServer.TLSConfig != nil {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.App.Error("Server error", "error", err)
 			os.Exit(1)
 		}
 	}()
 
+	if adminServer != nil {
+		go func() {
+			logger.App.Info("Admin server starting", "address", serverConfig.AdminAddress)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.App.Error("Admin server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// 설정 파일 변경 또는 SIGHUP 시 재시작 없이 설정 다시 읽기
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	watcher := config.NewWatcher(configWatchInterval, router.RouterConfigName, logger.LogConfigFileName)
+	go watcher.Run(watchCtx, gw.reload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.App.Info("SIGHUP received, reloading config")
+			gw.reload()
+		}
+	}()
+
+	// SIGUSR1이면 로그 파일을 바로 rotate한다 (로그 수집기와 맞추기 위함)
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			if err := logger.Rotate(); err != nil {
+				logger.App.Error("Log rotation failed", "error", err)
+				continue
+			}
+			logger.App.Info("Log files rotated")
+		}
+	}()
+
 	// 시그널 대기 (Ctrl+C, kill 등)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -72,9 +132,14 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := httpServer.Shutdown(ctx); err != nil {
 		logger.App.Error("Forced shutdown", "error", err)
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			logger.App.Error("Forced admin shutdown", "error", err)
+		}
+	}
 
 	logger.App.Info("Server stopped")
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"gateway-go/internal/auth"
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/router"
+	"gateway-go/internal/tracing"
+	"sync"
+)
+
+// gateway owns the parts of the running config that can be reloaded:
+// routes, auth proxies, log handlers and the tracer.
+type gateway struct {
+	mu       sync.Mutex
+	router   *router.Swappable
+	closeLog func()
+}
+
+// load reads config.yml and log.yml and installs them. Everything is parsed
+// and validated first, so a broken config leaves the running one in place.
+func (g *gateway) load() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	logConfigData, err := config.GetData(logger.LogConfigFileName)
+	if err != nil {
+		return fmt.Errorf("failed to read log config: %w", err)
+	}
+	logConfig, err := logger.ReadConfig(logConfigData)
+	if err != nil {
+		return fmt.Errorf("failed to parse log config: %w", err)
+	}
+
+	routerConfigData, err := config.GetData(router.RouterConfigName)
+	if err != nil {
+		return fmt.Errorf("failed to read router config: %w", err)
+	}
+	authStore, err := auth.LoadAuth(routerConfigData)
+	if err != nil {
+		return fmt.Errorf("failed to parse auth config: %w", err)
+	}
+	newRouter, err := router.NewRouterWithAuth(routerConfigData, authStore)
+	if err != nil {
+		return fmt.Errorf("failed to initialize router: %w", err)
+	}
+	tracingConfig, err := tracing.ReadConfig(routerConfigData)
+	if err != nil {
+		return fmt.Errorf("failed to parse tracing config: %w", err)
+	}
+
+	closeLog, err := logger.SetUp(logConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if g.closeLog != nil {
+		g.closeLog()
+	}
+	g.closeLog = closeLog
+
+	tracer := tracing.NewTracer(tracingConfig)
+	tracer.Start()
+	tracing.Replace(tracer).Close()
+
+	// router가 자신의 auth store를 들고 있으므로 router 교체 한 번으로
+	// 라우트와 auth provider가 함께 바뀐다
+	authStore.Start()
+	newRouter.Start()
+	if g.router == nil {
+		g.router = router.NewSwappable(newRouter)
+	} else {
+		g.router.Swap(newRouter).Close()
+	}
+	auth.Replace(authStore).Close()
+	return nil
+}
+
+// reload is load for a running gateway; failures are logged and the
+// previous config keeps serving.
+func (g *gateway) reload() {
+	if err := g.load(); err != nil {
+		logger.App.Error("Config reload failed, keeping previous config", "error", err)
+		return
+	}
+	logger.App.Info("Config reloaded")
+}
+
+func (g *gateway) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.router != nil {
+		g.router.Close()
+	}
+	auth.Current().Close()
+	// 남은 span을 내보낸 뒤 로그를 닫는다
+	tracing.Current().Close()
+	if g.closeLog != nil {
+		g.closeLog()
+	}
+}
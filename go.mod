@@ -4,6 +4,7 @@ go 1.25
 
 require (
 	github.com/golang-jwt/jwt/v5 v5.3.0
+	golang.org/x/crypto v0.45.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gopkg.in/yaml.v3 v3.0.1
 )
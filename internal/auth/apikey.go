@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyAuthConfig reads keys from a header and, if QueryParam is set, from
+// a query parameter. KeyFile lists the SHA-256 hashes of the accepted keys.
+type APIKeyAuthConfig struct {
+	Header     string `yaml:"header"`
+	QueryParam string `yaml:"query-param"`
+	KeyFile    string `yaml:"key-file"`
+}
+
+// APIKey is one entry of the key file. Hash is the hex encoded SHA-256 of
+// the key, so the file never holds the keys themselves.
+type APIKey struct {
+	ClientId string    `yaml:"client-id"`
+	Hash     string    `yaml:"hash"`
+	Roles    []string  `yaml:"roles"`
+	Expires  time.Time `yaml:"expires"`
+}
+
+type APIKeyAuthProxy struct {
+	header     string
+	queryParam string
+	keys       map[string]APIKey
+	now        func() time.Time
+}
+
+func (c APIKeyAuthConfig) toProxy() (APIKeyAuthProxy, error) {
+	if c.KeyFile == "" {
+		return APIKeyAuthProxy{}, errors.New("api-key-auth needs a key-file")
+	}
+	data, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return APIKeyAuthProxy{}, err
+	}
+	keys, err := parseAPIKeys(data)
+	if err != nil {
+		return APIKeyAuthProxy{}, err
+	}
+
+	header := c.Header
+	if header == "" {
+		header = defaultAPIKeyHeader
+	}
+	return APIKeyAuthProxy{
+		header:     header,
+		queryParam: c.QueryParam,
+		keys:       keys,
+		now:        time.Now,
+	}, nil
+}
+
+func parseAPIKeys(data []byte) (map[string]APIKey, error) {
+	var file struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	keys := make(map[string]APIKey, len(file.Keys))
+	for _, key := range file.Keys {
+		if key.ClientId == "" {
+			return nil, errors.New("api key needs a client-id")
+		}
+		hash := strings.ToLower(key.Hash)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("api key hash is not a sha256 hex digest: client-id=%q", key.ClientId)
+		}
+		if len(key.Roles) == 0 {
+			return nil, fmt.Errorf("api key needs roles: client-id=%q", key.ClientId)
+		}
+		if _, ok := keys[hash]; ok {
+			return nil, fmt.Errorf("duplicate api key: client-id=%q", key.ClientId)
+		}
+		keys[hash] = key
+	}
+	return keys, nil
+}
+
+// HashAPIKey returns the value stored in the key file for key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *APIKeyAuthProxy) Handle(r *http.Request) error {
+	value := r.Header.Get(a.header)
+	if value == "" && a.queryParam != "" {
+		value = r.URL.Query().Get(a.queryParam)
+	}
+	if value == "" {
+		return newAuthError(ReasonMissingCredentials, "API key is empty", nil)
+	}
+
+	key, ok := a.keys[HashAPIKey(value)]
+	if !ok {
+		return newAuthError(ReasonInvalidCredentials, "API key is invalid", nil)
+	}
+	if !key.Expires.IsZero() && !a.now().Before(key.Expires) {
+		return newAuthError(ReasonCredentialsExpired, "API key is expired", nil)
+	}
+
+	// 키가 upstream으로 전달되지 않도록 제거한다
+	r.Header.Del(a.header)
+	if a.queryParam != "" {
+		r.URL.RawQuery = removeQueryParam(r.URL.RawQuery, a.queryParam)
+	}
+
+	updateRequest(key.ClientId, key.Roles, r)
+	return nil
+}
+
+// removeQueryParam drops the name=value pairs for name from rawQuery and
+// keeps the other pairs in their original order and encoding.
+func removeQueryParam(rawQuery, name string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	parts := strings.Split(rawQuery, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		unescaped, err := url.QueryUnescape(key)
+		if err != nil {
+			unescaped = key
+		}
+		if unescaped != name {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// Challenge names the header the key is expected in. API keys have no
+// registered scheme, so clients only use it as a hint.
+func (a *APIKeyAuthProxy) Challenge(error) string {
+	return "APIKey header=" + strconv.Quote(a.header)
+}
+
+func (a *APIKeyAuthProxy) GetType() ProxyType {
+	return ProxyType(API_KEY)
+}
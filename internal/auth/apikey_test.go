@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyFile(t *testing.T) string {
+	content := fmt.Sprintf(`keys:
+  - client-id: billing
+    hash: %s
+    roles: [SERVICE, BILLING]
+  - client-id: legacy
+    hash: %s
+    roles: [SERVICE]
+    expires: 2026-01-01T00:00:00Z
+`, HashAPIKey("billing-key"), HashAPIKey("legacy-key"))
+	path := filepath.Join(t.TempDir(), "keys.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPIKeyAuthProxy(t *testing.T) {
+	proxy, err := APIKeyAuthConfig{KeyFile: writeKeyFile(t), QueryParam: "api_key"}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+	proxy.now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   string
+		reason string
+	}{
+		{"header key", "billing-key", "b=2&a=1", "b=2&a=1", ""},
+		{"query key", "", "api_key=billing-key&page=2", "page=2", ""},
+		// 나머지 파라미터의 순서와 인코딩은 그대로 유지한다
+		{"query key encoded", "", "b=2&api%5Fkey=billing-key&path=%2Fa+b&flag&api_key=x", "b=2&path=%2Fa+b&flag", ""},
+		{"missing key", "", "page=2", "", ReasonMissingCredentials},
+		{"unknown key", "other-key", "", "", ReasonInvalidCredentials},
+		{"expired key", "legacy-key", "", "", ReasonCredentialsExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &http.Request{
+				Header: http.Header{},
+				URL:    &url.URL{Path: "/api", RawQuery: tt.query},
+			}
+			if tt.header != "" {
+				request.Header.Set("X-API-Key", tt.header)
+			}
+
+			err := proxy.Handle(request)
+			if tt.reason != "" {
+				var authErr *AuthError
+				if !errors.As(err, &authErr) || authErr.Reason != tt.reason {
+					t.Fatalf("reason 불일치. 기대값: %s, 실제값: %v", tt.reason, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Auth handle fail ", err)
+			}
+			if request.Header.Get("X-User-Id") != "billing" {
+				t.Errorf("X-User-Id 불일치: %q", request.Header.Get("X-User-Id"))
+			}
+			if request.Header.Get("X-User-Role") != "SERVICE,BILLING" {
+				t.Errorf("X-User-Role 불일치: %q", request.Header.Get("X-User-Role"))
+			}
+			if request.Header.Get("X-API-Key") != "" || request.URL.Query().Has("api_key") {
+				t.Error("API key가 upstream 요청에 남아 있습니다")
+			}
+			if request.URL.RawQuery != tt.want {
+				t.Errorf("query 불일치. 기대값: %q, 실제값: %q", tt.want, request.URL.RawQuery)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFileValidation(t *testing.T) {
+	invalid := []string{
+		"keys:\n  - client-id: a\n    hash: plain-text-key\n    roles: [SERVICE]\n",
+		"keys:\n  - hash: " + HashAPIKey("a") + "\n    roles: [SERVICE]\n",
+		"keys:\n  - client-id: a\n    hash: " + HashAPIKey("a") + "\n",
+	}
+	for _, data := range invalid {
+		if _, err := parseAPIKeys([]byte(data)); err == nil {
+			t.Errorf("잘못된 key file이 허용되었습니다: %s", data)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package auth
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -11,25 +11,14 @@ import (
 type AuthType string
 
 const (
-	NONE AuthType = "NONE"
-	JWT  AuthType = "JWT"
+	NONE       AuthType = "NONE"
+	JWT        AuthType = "JWT"
+	API_KEY    AuthType = "API_KEY"
+	BASIC      AuthType = "BASIC"
+	INTROSPECT AuthType = "INTROSPECT"
+	MTLS       AuthType = "MTLS"
 )
 
-func ParseAuthType(value string) AuthType {
-	switch strings.ToUpper(value) {
-	case "JWT":
-		return JWT
-	default:
-		return NONE
-	}
-}
-
-type AuthError error
-
-func NewAuthError(message string) AuthError {
-	return errors.New(message)
-}
-
 type ProxyType AuthType
 
 type AuthProxy interface {
@@ -37,43 +26,131 @@ type AuthProxy interface {
 	GetType() ProxyType
 }
 
+// Challenger is implemented by proxies that tell a rejected client how to
+// authenticate, as the WWW-Authenticate header of the 401 response. err is
+// the failure returned by Handle.
+type Challenger interface {
+	Challenge(err error) string
+}
+
+const bearerPrefix = "Bearer "
+
 type JwtAuthProxy struct {
-	secret     string
-	authHeader string
-	Claims     Claims
+	secret         string
+	authHeader     string
+	Claims         Claims
+	algorithms     []string
+	jwks           *JWKS
+	parserOptions  []jwt.ParserOption
+	requiredClaims []requiredClaim
+
+	identityHeaders IdentityHeaders
+	claimHeaders    map[string]string
 }
 
+// Handle verifies the token and its claims and, on success, passes the
+// user id and roles upstream. Every failure is returned as an *AuthError.
 func (j *JwtAuthProxy) Handle(r *http.Request) error {
 	authValue := r.Header.Get(j.authHeader)
+	if len(authValue) > len(bearerPrefix) && strings.EqualFold(authValue[:len(bearerPrefix)], bearerPrefix) {
+		authValue = authValue[len(bearerPrefix):]
+	}
 	if authValue == "" {
-		return NewAuthError("Authentication header is empty")
+		return newAuthError(ReasonMissingCredentials, "Authentication header is empty", nil)
 	}
+
+	options := append([]jwt.ParserOption{jwt.WithValidMethods(j.algorithms)}, j.parserOptions...)
 	claims := jwt.MapClaims{}
-	key := []byte(j.secret)
-	token, err := jwt.ParseWithClaims(authValue, claims, func(t *jwt.Token) (interface{}, error) {
-		return key, nil
-	})
-	if err != nil || !token.Valid {
-		return err
+	token, err := jwt.ParseWithClaims(authValue, claims, j.keyFunc, options...)
+	if err != nil {
+		return tokenError(err)
+	}
+	if !token.Valid {
+		return NewAuthError("invalid token")
 	}
 
-	userId := claims[j.Claims.UserId].(string)
-	rolesInterface := claims["role"].([]interface{}) // interface{} 슬라이스로 가져오기
+	for _, required := range j.requiredClaims {
+		if err := required.check(claims); err != nil {
+			return err
+		}
+	}
 
-	roles := make([]string, len(rolesInterface))
-	if userId == "" || roles == nil || len(roles) == 0 {
-		return NewAuthError("")
+	userId, ok := claims[j.Claims.UserId].(string)
+	if !ok || userId == "" {
+		return newAuthError(ReasonMissingClaim, fmt.Sprintf("token is missing claim %q", j.Claims.UserId), nil)
+	}
+	roles, ok := stringValues(claims[j.Claims.Role])
+	if !ok || len(roles) == 0 {
+		return newAuthError(ReasonMissingClaim, fmt.Sprintf("token is missing claim %q", j.Claims.Role), nil)
 	}
 
-	updateRequest(userId, roles, r)
+	for header, claim := range j.claimHeaders {
+		if values, ok := stringValues(claims[claim]); ok {
+			r.Header.Set(header, strings.Join(values, ","))
+		}
+	}
+	j.identityHeaders.update(userId, roles, r)
 	return nil
 }
 
+func (j *JwtAuthProxy) Challenge(err error) string {
+	return bearerChallenge(err)
+}
+
+// InjectedHeaders returns the identity and claim headers set by Handle.
+func (j *JwtAuthProxy) InjectedHeaders() []string {
+	identityHeaders := j.identityHeaders.orDefault()
+	headers := []string{identityHeaders.UserId, identityHeaders.Role}
+	for header := range j.claimHeaders {
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// keyFunc selects the verification key. HMAC tokens use the shared secret,
+// asymmetric tokens use the JWKS key named by their kid header. The
+// algorithm itself is already restricted by jwt.WithValidMethods.
+func (j *JwtAuthProxy) keyFunc(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if j.secret == "" {
+			return nil, newAuthError(ReasonInvalidSignature, "HMAC tokens are not accepted", nil)
+		}
+		return []byte(j.secret), nil
+	}
+
+	if j.jwks == nil {
+		return nil, newAuthError(ReasonInvalidSignature, "no JWKS configured", nil)
+	}
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, newAuthError(ReasonInvalidSignature, "token has no kid", nil)
+	}
+	key, alg, err := j.jwks.Key(kid)
+	if err != nil {
+		return nil, newAuthError(ReasonInvalidSignature, "token key is unknown", err)
+	}
+	if alg != "" && alg != t.Method.Alg() {
+		return nil, newAuthError(ReasonInvalidSignature, "token algorithm does not match key", nil)
+	}
+	return key, nil
+}
+
 func (j *JwtAuthProxy) GetType() ProxyType {
 	return ProxyType(JWT)
 }
 
+func (j *JwtAuthProxy) Start() {
+	if j.jwks != nil {
+		j.jwks.Start()
+	}
+}
+
+func (j *JwtAuthProxy) Close() {
+	if j.jwks != nil {
+		j.jwks.Close()
+	}
+}
+
 func updateRequest(userId string, roles []string, r *http.Request) {
-	r.Header.Set("X-User-Id", userId)
-	r.Header.Set("X-User-Role", strings.Join(roles, ","))
+	defaultIdentityHeaders.update(userId, roles, r)
 }
@@ -1,13 +1,81 @@
 package auth
 
-import "strings"
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
-var store = map[string]AuthProxy{}
+// Store maps an auth type name to its proxy.
+type Store map[string]AuthProxy
+
+func (s Store) Get(typeValue string) AuthProxy {
+	return s[strings.ToUpper(typeValue)]
+}
+
+func (s Store) save(proxy AuthProxy) {
+	s[string(proxy.GetType())] = proxy
+}
+
+// lifecycle is implemented by proxies that run background work,
+// such as refreshing a JWKS.
+type lifecycle interface {
+	Start()
+	Close()
+}
+
+// Start begins the background work of every proxy in the store.
+func (s Store) Start() {
+	for _, proxy := range s {
+		if l, ok := proxy.(lifecycle); ok {
+			l.Start()
+		}
+	}
+}
+
+// Close stops the background work started by Start.
+func (s Store) Close() {
+	for _, proxy := range s {
+		if l, ok := proxy.(lifecycle); ok {
+			l.Close()
+		}
+	}
+}
+
+var (
+	store   atomic.Pointer[Store]
+	storeMu sync.Mutex
+)
+
+func init() {
+	store.Store(&Store{})
+}
 
 func Save(proxy AuthProxy) {
-	store[string(proxy.GetType())] = proxy
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	next := Store{}
+	for k, v := range Current() {
+		next[k] = v
+	}
+	next.save(proxy)
+	store.Store(&next)
 }
 
 func Get(typeValue string) AuthProxy {
-	return store[strings.ToUpper(typeValue)]
+	return Current().Get(typeValue)
+}
+
+// Current returns the active store. It must not be modified.
+func Current() Store {
+	return *store.Load()
+}
+
+// Replace atomically installs s as the active store and returns the
+// previous one.
+func Replace(s Store) Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return *store.Swap(&s)
 }
@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -45,3 +47,131 @@ func TestJwtAuthProxy(t *testing.T) {
 		t.Fatal("X-USER-ID is empty")
 	}
 }
+
+func TestJwtClaimValidation(t *testing.T) {
+	secretValue := "testsecrettestsecrettestsecrettestsecrettestsecrettestsecrettestsecret"
+	proxy, err := JwtAuthConfig{
+		Secret:     secretValue,
+		AuthHeader: "authentication",
+		Claims:     Claims{UserId: "userId", Role: "role"},
+		Issuer:     "https://idp.example.com",
+		Audiences:  []string{"gateway", "api"},
+		Leeway:     time.Minute,
+		RequiredClaims: []RequiredClaim{
+			{Name: "tenant", Value: "acme"},
+			{Name: "email", Pattern: "@example\\.com$"},
+			{Name: "groups", Value: "staff"},
+		},
+	}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"userId": "testUser",
+			"role":   []string{"ADMIN", "USER"},
+			"iss":    "https://idp.example.com",
+			"aud":    []string{"api"},
+			"exp":    time.Now().Add(-30 * time.Second).Unix(),
+			"tenant": "acme",
+			"email":  "user@example.com",
+			"groups": []string{"dev", "staff"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		reason string
+	}{
+		{"valid within leeway", func(c jwt.MapClaims) {}, ""},
+		{"expired beyond leeway", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }, ReasonTokenExpired},
+		{"not yet valid", func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(2 * time.Minute).Unix() }, ReasonTokenNotYetValid},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, ReasonInvalidIssuer},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other" }, ReasonInvalidAudience},
+		{"missing required claim", func(c jwt.MapClaims) { delete(c, "tenant") }, ReasonMissingClaim},
+		{"wrong claim value", func(c jwt.MapClaims) { c["tenant"] = "other" }, ReasonInvalidClaim},
+		{"claim pattern mismatch", func(c jwt.MapClaims) { c["email"] = "user@evil.com" }, ReasonInvalidClaim},
+		{"array claim mismatch", func(c jwt.MapClaims) { c["groups"] = []string{"dev"} }, ReasonInvalidClaim},
+		{"missing user id", func(c jwt.MapClaims) { delete(c, "userId") }, ReasonMissingClaim},
+		{"user id wrong type", func(c jwt.MapClaims) { c["userId"] = 42 }, ReasonMissingClaim},
+		{"role wrong type", func(c jwt.MapClaims) { c["role"] = 1 }, ReasonMissingClaim},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretValue))
+			if err != nil {
+				t.Fatal("token create fail ", err)
+			}
+
+			request := http.Request{Header: map[string][]string{}}
+			request.Header.Set("authentication", signed)
+			err = proxy.Handle(&request)
+
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("유효한 토큰 거부: %v", err)
+				}
+				if request.Header.Get("X-User-Role") != "ADMIN,USER" {
+					t.Errorf("role 헤더 불일치: %s", request.Header.Get("X-User-Role"))
+				}
+				return
+			}
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("AuthError 타입이 아님: %v", err)
+			}
+			if authErr.Reason != tt.reason {
+				t.Errorf("실패 사유 불일치. 기대값: %s, 실제값: %s (%v)", tt.reason, authErr.Reason, err)
+			}
+		})
+	}
+}
+
+func TestJwtHeaderMapping(t *testing.T) {
+	secretValue := "testsecrettestsecrettestsecrettestsecrettestsecrettestsecrettestsecret"
+	proxy, err := JwtAuthConfig{
+		Secret:       secretValue,
+		AuthHeader:   "Authorization",
+		Claims:       Claims{UserId: "sub", Role: "groups"},
+		UserIdHeader: "X-Subject",
+		RoleHeader:   "X-Groups",
+		Headers:      map[string]string{"X-Tenant-Id": "tenant"},
+	}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+
+	claims := jwt.MapClaims{"sub": "testUser", "groups": []string{"dev", "ops"}, "tenant": "acme"}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretValue))
+	if err != nil {
+		t.Fatal("token create fail ", err)
+	}
+	request := http.Request{Header: http.Header{}}
+	request.Header.Set("Authorization", "Bearer "+signed)
+	if err := proxy.Handle(&request); err != nil {
+		t.Fatal("Auth handle fail ", err)
+	}
+
+	expected := map[string]string{"X-Subject": "testUser", "X-Groups": "dev,ops", "X-Tenant-Id": "acme", "X-User-Id": ""}
+	for header, value := range expected {
+		if request.Header.Get(header) != value {
+			t.Errorf("%s 불일치. 기대값: %q, 실제값: %q", header, value, request.Header.Get(header))
+		}
+	}
+
+	injected := InjectedHeaders(Store{"JWT": &proxy})
+	for _, header := range []string{"X-User-Id", "X-User-Role", "X-Subject", "X-Groups", "X-Tenant-Id"} {
+		found := false
+		for _, h := range injected {
+			found = found || h == header
+		}
+		if !found {
+			t.Errorf("제거 대상 헤더에 %s가 없습니다", header)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 	"gopkg.in/yaml.v3"
 )
 
@@ -9,13 +14,33 @@ type AuthRoot struct {
 }
 
 type AuthConfig struct {
-	JwtAuth *JwtAuthConfig `yaml:"jwt-auth"`
+	JwtAuth        *JwtAuthConfig        `yaml:"jwt-auth"`
+	APIKeyAuth     *APIKeyAuthConfig     `yaml:"api-key-auth"`
+	BasicAuth      *BasicAuthConfig      `yaml:"basic-auth"`
+	IntrospectAuth *IntrospectAuthConfig `yaml:"introspect-auth"`
+	MTLSAuth       *MTLSAuthConfig       `yaml:"mtls-auth"`
 }
 
+// JwtAuthConfig verifies tokens with an HMAC secret, a JWKS, or both.
+// Algorithms defaults to HS256 for a secret and RS256/ES256 for a JWKS.
 type JwtAuthConfig struct {
-	Secret     string `yaml:"secret"`
-	AuthHeader string `yaml:"auth-header"`
-	Claims     Claims `json:"claims"`
+	Secret      string        `yaml:"secret"`
+	AuthHeader  string        `yaml:"auth-header"`
+	Claims      Claims        `json:"claims"`
+	JWKSURL     string        `yaml:"jwks-url"`
+	JWKSFile    string        `yaml:"jwks-file"`
+	JWKSRefresh time.Duration `yaml:"jwks-refresh"`
+	Algorithms  []string      `yaml:"algorithms"`
+
+	Issuer         string          `yaml:"issuer"`
+	Audiences      []string        `yaml:"audiences"`
+	Leeway         time.Duration   `yaml:"leeway"`
+	RequiredClaims []RequiredClaim `yaml:"required-claims"`
+
+	// 사용자 정보를 전달할 upstream 헤더 이름과 claim -> 헤더 매핑
+	UserIdHeader string            `yaml:"user-id-header"`
+	RoleHeader   string            `yaml:"role-header"`
+	Headers      map[string]string `yaml:"headers"`
 }
 
 type Claims struct {
@@ -23,7 +48,54 @@ type Claims struct {
 	Role   string `yaml:"role"`
 }
 
-func (j JwtAuthConfig) toProxy() JwtAuthProxy {
+func (j JwtAuthConfig) toProxy() (JwtAuthProxy, error) {
+	hasJWKS := j.JWKSURL != "" || j.JWKSFile != ""
+	if j.Secret == "" && !hasJWKS {
+		return JwtAuthProxy{}, errors.New("jwt-auth needs a secret or a jwks")
+	}
+
+	algorithms := j.Algorithms
+	if len(algorithms) == 0 {
+		if j.Secret != "" {
+			algorithms = append(algorithms, jwt.SigningMethodHS256.Alg())
+		}
+		if hasJWKS {
+			algorithms = append(algorithms, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+		}
+	}
+	for _, alg := range algorithms {
+		method := jwt.GetSigningMethod(alg)
+		if method == nil || method == jwt.SigningMethodNone {
+			return JwtAuthProxy{}, fmt.Errorf("unsupported jwt algorithm: %q", alg)
+		}
+	}
+
+	requiredClaims, err := compileRequiredClaims(j.RequiredClaims)
+	if err != nil {
+		return JwtAuthProxy{}, err
+	}
+	if j.Leeway < 0 {
+		return JwtAuthProxy{}, errors.New("leeway must not be negative")
+	}
+	var parserOptions []jwt.ParserOption
+	if j.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(j.Issuer))
+	}
+	if len(j.Audiences) > 0 {
+		parserOptions = append(parserOptions, jwt.WithAudience(j.Audiences...))
+	}
+	if j.Leeway > 0 {
+		parserOptions = append(parserOptions, jwt.WithLeeway(j.Leeway))
+	}
+
+	var jwks *JWKS
+	if hasJWKS {
+		jwks, err = NewJWKS(j.JWKSURL, j.JWKSFile, j.JWKSRefresh)
+		if err != nil {
+			return JwtAuthProxy{}, err
+		}
+	}
+
 	return JwtAuthProxy{
 		secret:     j.Secret,
 		authHeader: j.AuthHeader,
@@ -31,20 +103,71 @@ func (j JwtAuthConfig) toProxy() JwtAuthProxy {
 			UserId: j.Claims.UserId,
 			Role:   j.Claims.Role,
 		},
-	}
+		algorithms:     algorithms,
+		jwks:           jwks,
+		parserOptions:  parserOptions,
+		requiredClaims: requiredClaims,
+
+		identityHeaders: newIdentityHeaders(j.UserIdHeader, j.RoleHeader),
+		claimHeaders:    j.Headers,
+	}, nil
 }
 
 func SetUpAuth(data []byte) error {
+	loaded, err := LoadAuth(data)
+	if err != nil {
+		return err
+	}
+	for _, proxy := range loaded {
+		Save(proxy)
+	}
+	return nil
+}
+
+// LoadAuth builds the auth proxies configured in data without installing them.
+func LoadAuth(data []byte) (Store, error) {
 	var config AuthRoot
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	loaded := Store{}
 	auth := config.Auth.JwtAuth
 	if auth != nil {
-		proxy := auth.toProxy()
-		Save(&proxy)
+		proxy, err := auth.toProxy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt-auth: %w", err)
+		}
+		loaded.save(&proxy)
 	}
-	return nil
+	if config.Auth.APIKeyAuth != nil {
+		proxy, err := config.Auth.APIKeyAuth.toProxy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid api-key-auth: %w", err)
+		}
+		loaded.save(&proxy)
+	}
+	if config.Auth.BasicAuth != nil {
+		proxy, err := config.Auth.BasicAuth.toProxy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid basic-auth: %w", err)
+		}
+		loaded.save(&proxy)
+	}
+	if config.Auth.IntrospectAuth != nil {
+		proxy, err := config.Auth.IntrospectAuth.toProxy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid introspect-auth: %w", err)
+		}
+		loaded.save(&proxy)
+	}
+	if config.Auth.MTLSAuth != nil {
+		proxy, err := config.Auth.MTLSAuth.toProxy()
+		if err != nil {
+			return nil, fmt.Errorf("invalid mtls-auth: %w", err)
+		}
+		loaded.save(&proxy)
+	}
+	return loaded, nil
 }
@@ -19,7 +19,7 @@ auth:
 		t.Errorf("setup fail %v", err)
 	}
 
-	proxy := Get("json")
+	proxy := Get("jwt")
 
 	if proxy == nil {
 		t.Fatal("proxy is not maked")
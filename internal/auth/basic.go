@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultBasicRealm = "gateway"
+
+// BasicAuthConfig checks HTTP Basic credentials against an htpasswd file.
+// Every user is given Roles, since htpasswd has no place to store them.
+type BasicAuthConfig struct {
+	HtpasswdFile string        `yaml:"htpasswd-file"`
+	Realm        string        `yaml:"realm"`
+	Refresh      time.Duration `yaml:"refresh"`
+	Roles        []string      `yaml:"roles"`
+}
+
+type BasicAuthProxy struct {
+	htpasswd *Htpasswd
+	realm    string
+	roles    []string
+}
+
+func (c BasicAuthConfig) toProxy() (BasicAuthProxy, error) {
+	if len(c.Roles) == 0 {
+		return BasicAuthProxy{}, errors.New("basic-auth needs roles")
+	}
+	htpasswd, err := NewHtpasswd(c.HtpasswdFile, c.Refresh)
+	if err != nil {
+		return BasicAuthProxy{}, err
+	}
+	realm := c.Realm
+	if realm == "" {
+		realm = defaultBasicRealm
+	}
+	return BasicAuthProxy{
+		htpasswd: htpasswd,
+		realm:    realm,
+		roles:    c.Roles,
+	}, nil
+}
+
+func (b *BasicAuthProxy) Handle(r *http.Request) error {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return newAuthError(ReasonMissingCredentials, "Basic credentials are empty", nil)
+	}
+	if !b.htpasswd.Verify(user, password) {
+		return newAuthError(ReasonInvalidCredentials, "username or password is invalid", nil)
+	}
+
+	// 비밀번호가 upstream으로 전달되지 않도록 제거한다
+	r.Header.Del("Authorization")
+	updateRequest(user, b.roles, r)
+	return nil
+}
+
+// Challenge returns the WWW-Authenticate value sent with a 401.
+func (b *BasicAuthProxy) Challenge(error) string {
+	return "Basic realm=" + strconv.Quote(b.realm) + `, charset="UTF-8"`
+}
+
+func (b *BasicAuthProxy) GetType() ProxyType {
+	return ProxyType(BASIC)
+}
+
+func (b *BasicAuthProxy) Start() {
+	b.htpasswd.Start()
+}
+
+func (b *BasicAuthProxy) Close() {
+	b.htpasswd.Close()
+}
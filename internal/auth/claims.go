@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RequiredClaim is an entry of required-claims in jwt-auth. The claim must
+// be present; when Value is set it must be equal, when Pattern is set it
+// must match. For array claims one matching element is enough.
+type RequiredClaim struct {
+	Name    string `yaml:"name"`
+	Value   string `yaml:"value"`
+	Pattern string `yaml:"pattern"`
+}
+
+type requiredClaim struct {
+	name    string
+	value   string
+	pattern *regexp.Regexp
+}
+
+func compileRequiredClaims(claims []RequiredClaim) ([]requiredClaim, error) {
+	result := make([]requiredClaim, len(claims))
+	for i, claim := range claims {
+		if claim.Name == "" {
+			return nil, fmt.Errorf("required claim needs a name")
+		}
+		result[i] = requiredClaim{name: claim.Name, value: claim.Value}
+		if claim.Pattern != "" {
+			pattern, err := regexp.Compile(claim.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for claim %q: %w", claim.Name, err)
+			}
+			result[i].pattern = pattern
+		}
+	}
+	return result, nil
+}
+
+func (c requiredClaim) check(claims map[string]interface{}) *AuthError {
+	raw, ok := claims[c.name]
+	if !ok || raw == nil {
+		return newAuthError(ReasonMissingClaim, fmt.Sprintf("token is missing claim %q", c.name), nil)
+	}
+	if c.value == "" && c.pattern == nil {
+		return nil
+	}
+
+	values, ok := stringValues(raw)
+	if ok {
+		for _, v := range values {
+			if c.matches(v) {
+				return nil
+			}
+		}
+	}
+	return newAuthError(ReasonInvalidClaim, fmt.Sprintf("token claim %q has unexpected value", c.name), nil)
+}
+
+func (c requiredClaim) matches(value string) bool {
+	if c.value != "" && value != c.value {
+		return false
+	}
+	return c.pattern == nil || c.pattern.MatchString(value)
+}
+
+// stringValues reads a claim that is a string or an array of strings.
+func stringValues(raw interface{}) ([]string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	case []string:
+		return v, true
+	default:
+		return nil, false
+	}
+}
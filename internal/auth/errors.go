@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Reason codes describing why authentication failed.
+const (
+	ReasonMissingCredentials  = "missing_credentials"
+	ReasonInvalidToken        = "invalid_token"
+	ReasonInvalidSignature    = "invalid_signature"
+	ReasonTokenExpired        = "token_expired"
+	ReasonTokenNotYetValid    = "token_not_yet_valid"
+	ReasonInvalidIssuer       = "invalid_issuer"
+	ReasonInvalidAudience     = "invalid_audience"
+	ReasonMissingClaim        = "missing_claim"
+	ReasonInvalidClaim        = "invalid_claim"
+	ReasonInvalidCredentials  = "invalid_credentials"
+	ReasonCredentialsExpired  = "credentials_expired"
+	ReasonIntrospectionFailed = "introspection_failed"
+	ReasonForbidden           = "forbidden"
+)
+
+// AuthError is returned by AuthProxy.Handle for every authentication failure.
+// Provider is the name of the provider that failed, set by Authenticate.
+type AuthError struct {
+	Reason   string
+	Message  string
+	Provider string
+	Err      error
+}
+
+func NewAuthError(message string) *AuthError {
+	return &AuthError{Reason: ReasonInvalidToken, Message: message}
+}
+
+func newAuthError(reason, message string, err error) *AuthError {
+	return &AuthError{Reason: reason, Message: message, Err: err}
+}
+
+func (e *AuthError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf returns the reason code of err, or ReasonInvalidCredentials for
+// errors that are not an *AuthError.
+func ReasonOf(err error) string {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.Reason
+	}
+	return ReasonInvalidCredentials
+}
+
+// Unavailable reports whether err means the gateway could not check the
+// credentials, for example because the introspection endpoint failed,
+// rather than that they were rejected. Clients should retry with the same
+// credentials instead of discarding them.
+func Unavailable(err error) bool {
+	return ReasonOf(err) == ReasonIntrospectionFailed
+}
+
+// ProviderOf returns the provider that produced err, if known.
+func ProviderOf(err error) string {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.Provider
+	}
+	return ""
+}
+
+// MessageOf returns a message for err that is safe to send to the client.
+func MessageOf(err error) string {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.Message
+	}
+	return "authentication failed"
+}
+
+// bearerChallenge follows RFC 6750: the error attribute is only sent when
+// the client presented a token.
+func bearerChallenge(err error) string {
+	challenge := `Bearer realm="gateway"`
+	if err != nil && ReasonOf(err) != ReasonMissingCredentials {
+		challenge += `, error="invalid_token", error_description=` + strconv.Quote(MessageOf(err))
+	}
+	return challenge
+}
+
+// tokenError converts an error from the jwt parser into an AuthError.
+func tokenError(err error) *AuthError {
+	var authErr *AuthError
+	switch {
+	case errors.As(err, &authErr):
+		return authErr
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return newAuthError(ReasonTokenExpired, "token is expired", err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return newAuthError(ReasonTokenNotYetValid, "token is not valid yet", err)
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return newAuthError(ReasonInvalidIssuer, "token has invalid issuer", err)
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return newAuthError(ReasonInvalidAudience, "token has invalid audience", err)
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return newAuthError(ReasonMissingClaim, "token is missing required claim", err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return newAuthError(ReasonInvalidSignature, "token signature is invalid", err)
+	default:
+		return newAuthError(ReasonInvalidToken, "token is invalid", err)
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultUserIdHeader = "X-User-Id"
+	defaultRoleHeader   = "X-User-Role"
+)
+
+// IdentityHeaders names the upstream headers that carry the caller.
+type IdentityHeaders struct {
+	UserId string
+	Role   string
+}
+
+var defaultIdentityHeaders = IdentityHeaders{UserId: defaultUserIdHeader, Role: defaultRoleHeader}
+
+func newIdentityHeaders(userId, role string) IdentityHeaders {
+	headers := defaultIdentityHeaders
+	if userId != "" {
+		headers.UserId = userId
+	}
+	if role != "" {
+		headers.Role = role
+	}
+	return headers
+}
+
+func (h IdentityHeaders) orDefault() IdentityHeaders {
+	if h == (IdentityHeaders{}) {
+		return defaultIdentityHeaders
+	}
+	return h
+}
+
+func (h IdentityHeaders) update(userId string, roles []string, r *http.Request) {
+	h = h.orDefault()
+	r.Header.Set(h.UserId, userId)
+	r.Header.Set(h.Role, strings.Join(roles, ","))
+	setIdentity(r, Identity{UserId: userId, Roles: roles})
+}
+
+// headerInjector is implemented by proxies that set upstream headers other
+// than the default identity headers.
+type headerInjector interface {
+	InjectedHeaders() []string
+}
+
+// InjectedHeaders returns every header a proxy in store may set upstream,
+// including the default identity headers. Clients must not be able to send
+// these, so the gateway removes them from inbound requests before auth.
+func InjectedHeaders(store Store) []string {
+	headers := []string{defaultUserIdHeader, defaultRoleHeader}
+	for _, proxy := range store {
+		if injector, ok := proxy.(headerInjector); ok {
+			headers = append(headers, injector.InjectedHeaders()...)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gateway-go/internal/logger"
+	"hash"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultHtpasswdRefresh = 5 * time.Second
+
+// Htpasswd holds the users of an htpasswd file. Started, it polls the file
+// and reloads it when its modification time changes.
+type Htpasswd struct {
+	file    string
+	refresh time.Duration
+
+	mu      sync.RWMutex
+	users   map[string]string
+	dummy   string
+	modTime time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewHtpasswd(file string, refresh time.Duration) (*Htpasswd, error) {
+	if file == "" {
+		return nil, errors.New("htpasswd file is empty")
+	}
+	if refresh <= 0 {
+		refresh = defaultHtpasswdRefresh
+	}
+	h := &Htpasswd{
+		file:    file,
+		refresh: refresh,
+		stop:    make(chan struct{}),
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Htpasswd) Start() {
+	go func() {
+		ticker := time.NewTicker(h.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+			if err := h.reloadIfChanged(); err != nil {
+				logger.App.Warn("Failed to reload htpasswd", "file", h.file, "error", err)
+			}
+		}
+	}()
+}
+
+func (h *Htpasswd) Close() {
+	h.once.Do(func() {
+		close(h.stop)
+	})
+}
+
+// Verify reports whether password matches the stored hash of user. An
+// unknown user is checked against a dummy hash, so the response time does
+// not reveal which users exist.
+func (h *Htpasswd) Verify(user, password string) bool {
+	h.mu.RLock()
+	stored, ok := h.users[user]
+	dummy := h.dummy
+	h.mu.RUnlock()
+	if !ok {
+		verifyPassword(dummy, password)
+		return false
+	}
+	return verifyPassword(stored, password)
+}
+
+// dummyHash picks the stored hash an unknown user is checked against.
+// bcrypt is preferred since it is the slowest scheme in most files.
+func dummyHash(users map[string]string) string {
+	dummy := ""
+	for _, stored := range users {
+		if strings.HasPrefix(stored, "$2") {
+			return stored
+		}
+		dummy = stored
+	}
+	return dummy
+}
+
+func (h *Htpasswd) reloadIfChanged() error {
+	info, err := os.Stat(h.file)
+	if err != nil {
+		return err
+	}
+	h.mu.RLock()
+	unchanged := info.ModTime().Equal(h.modTime)
+	h.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	if err := h.load(); err != nil {
+		return err
+	}
+	logger.App.Info("htpasswd reloaded", "file", h.file)
+	return nil
+}
+
+func (h *Htpasswd) load() error {
+	info, err := os.Stat(h.file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(h.file)
+	if err != nil {
+		return err
+	}
+	users, err := parseHtpasswd(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.users = users
+	h.dummy = dummyHash(users)
+	h.modTime = info.ModTime()
+	h.mu.Unlock()
+	return nil
+}
+
+func parseHtpasswd(data []byte) (map[string]string, error) {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		user, stored, ok := strings.Cut(text, ":")
+		if !ok || user == "" || stored == "" {
+			return nil, fmt.Errorf("invalid htpasswd entry at line %d", line)
+		}
+		if !isSupportedHash(stored) {
+			return nil, fmt.Errorf("unsupported htpasswd hash for user %q", user)
+		}
+		users[user] = stored
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// 지원하는 형식: bcrypt($2a$, $2b$, $2y$), {SHA}, SHA-crypt($5$, $6$)
+func isSupportedHash(stored string) bool {
+	switch {
+	case strings.HasPrefix(stored, "$2a$"), strings.HasPrefix(stored, "$2b$"), strings.HasPrefix(stored, "$2y$"):
+		return true
+	case strings.HasPrefix(stored, "{SHA}"):
+		return true
+	case strings.HasPrefix(stored, "$5$"), strings.HasPrefix(stored, "$6$"):
+		return true
+	default:
+		return false
+	}
+}
+
+func verifyPassword(stored, password string) bool {
+	switch {
+	case strings.HasPrefix(stored, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case strings.HasPrefix(stored, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(expected)) == 1
+	case strings.HasPrefix(stored, "$5$"), strings.HasPrefix(stored, "$6$"):
+		computed, err := shaCrypt(password, stored)
+		if err != nil {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(computed)) == 1
+	default:
+		return false
+	}
+}
+
+const (
+	shaCryptDefaultRounds = 5000
+	shaCryptMinRounds     = 1000
+	shaCryptMaxRounds     = 999999999
+	shaCryptMaxSalt       = 16
+	cryptAlphabet         = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// byte order of the final digest in the SHA-crypt encoding
+var (
+	sha256CryptOrder = []int{
+		0, 10, 20, 21, 1, 11, 12, 22, 2, 3, 13, 23, 24, 4, 14,
+		15, 25, 5, 6, 16, 26, 27, 7, 17, 18, 28, 8, 9, 19, 29, 31, 30,
+	}
+	sha512CryptOrder = []int{
+		0, 21, 42, 22, 43, 1, 44, 2, 23, 3, 24, 45, 25, 46, 4,
+		47, 5, 26, 6, 27, 48, 28, 49, 7, 50, 8, 29, 9, 30, 51,
+		31, 52, 10, 53, 11, 32, 12, 33, 54, 34, 55, 13, 56, 14, 35,
+		15, 36, 57, 37, 58, 16, 59, 17, 38, 18, 39, 60, 40, 61, 19,
+		62, 20, 41, 63,
+	}
+)
+
+// shaCrypt hashes password with the prefix, rounds and salt of setting,
+// following the SHA-crypt specification used by $5$ and $6$ hashes.
+func shaCrypt(password, setting string) (string, error) {
+	var newHash func() hash.Hash
+	var order []int
+	prefix := setting[:3]
+	switch prefix {
+	case "$5$":
+		newHash, order = sha256.New, sha256CryptOrder
+	case "$6$":
+		newHash, order = sha512.New, sha512CryptOrder
+	default:
+		return "", errors.New("unsupported sha-crypt prefix")
+	}
+
+	rest := setting[3:]
+	rounds := shaCryptDefaultRounds
+	customRounds := false
+	if value, ok := strings.CutPrefix(rest, "rounds="); ok {
+		number, remain, found := strings.Cut(value, "$")
+		if !found {
+			return "", errors.New("invalid sha-crypt rounds")
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return "", errors.New("invalid sha-crypt rounds")
+		}
+		rounds = min(max(n, shaCryptMinRounds), shaCryptMaxRounds)
+		customRounds = true
+		rest = remain
+	}
+	salt, _, _ := strings.Cut(rest, "$")
+	if len(salt) > shaCryptMaxSalt {
+		salt = salt[:shaCryptMaxSalt]
+	}
+
+	pw := []byte(password)
+	saltBytes := []byte(salt)
+
+	b := newHash()
+	b.Write(pw)
+	b.Write(saltBytes)
+	b.Write(pw)
+	digestB := b.Sum(nil)
+
+	a := newHash()
+	a.Write(pw)
+	a.Write(saltBytes)
+	a.Write(repeatBytes(digestB, len(pw)))
+	for n := len(pw); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			a.Write(digestB)
+		} else {
+			a.Write(pw)
+		}
+	}
+	digestA := a.Sum(nil)
+
+	dp := newHash()
+	for range len(pw) {
+		dp.Write(pw)
+	}
+	p := repeatBytes(dp.Sum(nil), len(pw))
+
+	ds := newHash()
+	for range 16 + int(digestA[0]) {
+		ds.Write(saltBytes)
+	}
+	s := repeatBytes(ds.Sum(nil), len(saltBytes))
+
+	c := digestA
+	for i := range rounds {
+		round := newHash()
+		if i%2 != 0 {
+			round.Write(p)
+		} else {
+			round.Write(c)
+		}
+		if i%3 != 0 {
+			round.Write(s)
+		}
+		if i%7 != 0 {
+			round.Write(p)
+		}
+		if i%2 != 0 {
+			round.Write(c)
+		} else {
+			round.Write(p)
+		}
+		c = round.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	if customRounds {
+		out.WriteString("rounds=" + strconv.Itoa(rounds) + "$")
+	}
+	out.WriteString(salt)
+	out.WriteByte('$')
+	out.WriteString(encodeCrypt(c, order))
+	return out.String(), nil
+}
+
+func repeatBytes(src []byte, length int) []byte {
+	out := make([]byte, 0, length)
+	for len(out) < length {
+		out = append(out, src[:min(len(src), length-len(out))]...)
+	}
+	return out
+}
+
+// encodeCrypt encodes digest in the crypt base64 alphabet, taking bytes
+// three at a time in the given order, least significant bits first.
+func encodeCrypt(digest []byte, order []int) string {
+	var out strings.Builder
+	for i := 0; i < len(order); i += 3 {
+		group := order[i:min(i+3, len(order))]
+		var w uint
+		chars := len(group) + 1
+		for _, index := range group {
+			w = w<<8 | uint(digest[index])
+		}
+		for range chars {
+			out.WriteByte(cryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestShaCrypt(t *testing.T) {
+	tests := []struct {
+		setting  string
+		expected string
+	}{
+		{"$5$saltstring", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"},
+		{"$5$rounds=10000$saltstringsaltstring", "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA"},
+		{"$6$saltstring", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+	}
+	for _, tt := range tests {
+		actual, err := shaCrypt("Hello world!", tt.setting)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != tt.expected {
+			t.Errorf("hash 불일치. 기대값: %s, 실제값: %s", tt.expected, actual)
+		}
+	}
+}
+
+func TestBasicAuthProxy(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("admin-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	content := "# admins\n" +
+		"admin:" + string(bcryptHash) + "\n" +
+		"ops:{SHA}0DPiKuNIrrVmD8IUCuw1hQxNqZc=\n" +
+		"legacy:$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	proxy, err := BasicAuthConfig{HtpasswdFile: path, Realm: "admin", Roles: []string{"ADMIN"}}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+	if proxy.Challenge(nil) != `Basic realm="admin", charset="UTF-8"` {
+		t.Errorf("WWW-Authenticate 불일치: %s", proxy.Challenge(nil))
+	}
+
+	tests := []struct {
+		user     string
+		password string
+		reason   string
+	}{
+		{"admin", "admin-pass", ""},
+		{"ops", "admin", ""},
+		{"legacy", "Hello world!", ""},
+		{"admin", "wrong", ReasonInvalidCredentials},
+		{"nobody", "admin-pass", ReasonInvalidCredentials},
+	}
+	for _, tt := range tests {
+		request, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+		request.SetBasicAuth(tt.user, tt.password)
+		err := proxy.Handle(request)
+		if tt.reason != "" {
+			var authErr *AuthError
+			if !errors.As(err, &authErr) || authErr.Reason != tt.reason {
+				t.Errorf("%s: reason 불일치. 기대값: %s, 실제값: %v", tt.user, tt.reason, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Auth handle fail %v", tt.user, err)
+			continue
+		}
+		if request.Header.Get("X-User-Id") != tt.user || request.Header.Get("Authorization") != "" {
+			t.Errorf("%s: upstream 헤더 불일치: %v", tt.user, request.Header)
+		}
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+	var authErr *AuthError
+	if err := proxy.Handle(request); !errors.As(err, &authErr) || authErr.Reason != ReasonMissingCredentials {
+		t.Errorf("credential 없는 요청 결과 불일치: %v", err)
+	}
+}
+
+func TestHtpasswdUnknownUser(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("admin-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]string{
+		"ops":   "{SHA}0DPiKuNIrrVmD8IUCuw1hQxNqZc=",
+		"admin": string(bcryptHash),
+	}
+	// 없는 사용자도 가장 느린 hash로 비교해 응답 시간으로 사용자를 알 수 없게 한다
+	if dummy := dummyHash(users); dummy != string(bcryptHash) {
+		t.Errorf("dummy hash가 bcrypt가 아닙니다: %s", dummy)
+	}
+
+	h := &Htpasswd{users: users, dummy: dummyHash(users)}
+	if h.Verify("nobody", "admin-pass") {
+		t.Error("dummy hash와 일치하는 비밀번호로 없는 사용자가 인증됨")
+	}
+	if !h.Verify("admin", "admin-pass") {
+		t.Error("등록된 사용자 인증 실패")
+	}
+}
+
+func TestHtpasswdReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("ops:{SHA}0DPiKuNIrrVmD8IUCuw1hQxNqZc=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	htpasswd, err := NewHtpasswd(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	htpasswd.Start()
+	defer htpasswd.Close()
+
+	if !htpasswd.Verify("ops", "admin") {
+		t.Fatal("초기 사용자가 인증되지 않습니다")
+	}
+
+	// {SHA} of "changed"
+	if err := os.WriteFile(path, []byte("ops:{SHA}N8bFe+30MF70EknBeUdgtcuPrRc=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for htpasswd.Verify("ops", "admin") {
+		if time.Now().After(deadline) {
+			t.Fatal("변경된 htpasswd 파일이 다시 읽히지 않았습니다")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !htpasswd.Verify("ops", "changed") {
+		t.Error("변경된 비밀번호로 인증되지 않습니다")
+	}
+}
+
+func TestHtpasswdValidation(t *testing.T) {
+	invalid := []string{
+		"admin\n",
+		"admin:plaintext\n",
+		"admin:$apr1$salt$hash\n",
+	}
+	for _, data := range invalid {
+		if _, err := parseHtpasswd([]byte(data)); err == nil {
+			t.Errorf("잘못된 htpasswd가 허용되었습니다: %q", data)
+		}
+	}
+}
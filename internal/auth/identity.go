@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+type identityKey struct{}
+
+// Identity is the authenticated caller of a request. Peer is the client
+// certificate identity set by MTLSAuthProxy; it is kept when a later
+// provider in the chain sets the user.
+type Identity struct {
+	UserId string
+	Roles  []string
+	Peer   string
+}
+
+// IdentityFrom returns the identity stored by a successful AuthProxy.Handle.
+func IdentityFrom(r *http.Request) (Identity, bool) {
+	identity, ok := r.Context().Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// WithIdentity returns a copy of ctx carrying identity, as a successful
+// AuthProxy.Handle leaves it on the request.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func setIdentity(r *http.Request, identity Identity) {
+	if previous, ok := IdentityFrom(r); ok && identity.Peer == "" {
+		identity.Peer = previous.Peer
+	}
+	*r = *r.WithContext(WithIdentity(r.Context(), identity))
+}
+
+func setPeer(r *http.Request, peer string) {
+	identity, _ := IdentityFrom(r)
+	identity.Peer = peer
+	*r = *r.WithContext(WithIdentity(r.Context(), identity))
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIntrospectTimeout  = 5 * time.Second
+	defaultIntrospectCache    = 10000
+	defaultIntrospectMaxTTL   = 5 * time.Minute
+	defaultIntrospectNegative = 30 * time.Second
+	maxIntrospectSize         = 1 << 20
+)
+
+// IntrospectAuthConfig verifies opaque tokens with an OAuth2 introspection
+// endpoint (RFC 7662). Claims default to sub and scope; Headers maps extra
+// response fields to upstream request headers. Both fields are optional in
+// RFC 7662, so the user id falls back to client_id and then username, and a
+// token without scope has no roles.
+type IntrospectAuthConfig struct {
+	Endpoint     string            `yaml:"endpoint"`
+	ClientId     string            `yaml:"client-id"`
+	ClientSecret string            `yaml:"client-secret"`
+	AuthHeader   string            `yaml:"auth-header"`
+	Timeout      time.Duration     `yaml:"timeout"`
+	Claims       Claims            `yaml:"claims"`
+	Headers      map[string]string `yaml:"headers"`
+
+	CacheSize        int           `yaml:"cache-size"`
+	CacheMaxTTL      time.Duration `yaml:"cache-max-ttl"`
+	NegativeCacheTTL time.Duration `yaml:"negative-cache-ttl"`
+}
+
+type IntrospectAuthProxy struct {
+	endpoint     string
+	clientId     string
+	clientSecret string
+	authHeader   string
+	client       *http.Client
+	claims       Claims
+	headers      map[string]string
+	maxTTL       time.Duration
+	negativeTTL  time.Duration
+	cache        *tokenCache
+	now          func() time.Time
+}
+
+// introspection is the cached outcome of introspecting one token.
+type introspection struct {
+	active  bool
+	userId  string
+	roles   []string
+	headers map[string]string
+}
+
+func (c IntrospectAuthConfig) toProxy() (IntrospectAuthProxy, error) {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return IntrospectAuthProxy{}, fmt.Errorf("invalid introspection endpoint: %q", c.Endpoint)
+	}
+	if c.ClientId == "" {
+		return IntrospectAuthProxy{}, errors.New("introspect-auth needs a client-id")
+	}
+	if c.CacheSize < 0 || c.CacheMaxTTL < 0 || c.NegativeCacheTTL < 0 || c.Timeout < 0 {
+		return IntrospectAuthProxy{}, errors.New("introspect-auth durations and cache-size must not be negative")
+	}
+
+	authHeader := c.AuthHeader
+	if authHeader == "" {
+		authHeader = "Authorization"
+	}
+	claims := c.Claims
+	if claims.UserId == "" {
+		claims.UserId = "sub"
+	}
+	if claims.Role == "" {
+		claims.Role = "scope"
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultIntrospectTimeout
+	}
+	cacheSize := c.CacheSize
+	if cacheSize == 0 {
+		cacheSize = defaultIntrospectCache
+	}
+	maxTTL := c.CacheMaxTTL
+	if maxTTL == 0 {
+		maxTTL = defaultIntrospectMaxTTL
+	}
+	negativeTTL := c.NegativeCacheTTL
+	if negativeTTL == 0 {
+		negativeTTL = defaultIntrospectNegative
+	}
+
+	return IntrospectAuthProxy{
+		endpoint:     c.Endpoint,
+		clientId:     c.ClientId,
+		clientSecret: c.ClientSecret,
+		authHeader:   authHeader,
+		client:       &http.Client{Timeout: timeout},
+		claims:       claims,
+		headers:      c.Headers,
+		maxTTL:       maxTTL,
+		negativeTTL:  negativeTTL,
+		cache:        newTokenCache(cacheSize),
+		now:          time.Now,
+	}, nil
+}
+
+func (i *IntrospectAuthProxy) Handle(r *http.Request) error {
+	token := r.Header.Get(i.authHeader)
+	if len(token) > len(bearerPrefix) && strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) {
+		token = token[len(bearerPrefix):]
+	}
+	if token == "" {
+		return newAuthError(ReasonMissingCredentials, "Authentication header is empty", nil)
+	}
+
+	key := HashAPIKey(token)
+	result, ok := i.cache.get(key, i.now())
+	if !ok {
+		var ttl time.Duration
+		var err error
+		result, ttl, err = i.introspect(r.Context(), token)
+		if err != nil {
+			return newAuthError(ReasonIntrospectionFailed, "token introspection failed", err)
+		}
+		if ttl > 0 {
+			i.cache.add(key, result, i.now().Add(ttl))
+		}
+	}
+	if !result.active {
+		return newAuthError(ReasonInvalidToken, "token is not active", nil)
+	}
+	if result.userId == "" {
+		return newAuthError(ReasonMissingClaim, "token has no user id", nil)
+	}
+
+	for name, value := range result.headers {
+		r.Header.Set(name, value)
+	}
+	updateRequest(result.userId, result.roles, r)
+	return nil
+}
+
+// introspect asks the endpoint about token and returns the result together
+// with how long it may be cached.
+func (i *IntrospectAuthProxy) introspect(ctx context.Context, token string) (introspection, time.Duration, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return introspection{}, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.clientId), url.QueryEscape(i.clientSecret))
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return introspection{}, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return introspection{}, 0, fmt.Errorf("unexpected introspection status: %d", resp.StatusCode)
+	}
+
+	var fields map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIntrospectSize)).Decode(&fields); err != nil {
+		return introspection{}, 0, fmt.Errorf("invalid introspection response: %w", err)
+	}
+
+	if active, _ := fields["active"].(bool); !active {
+		return introspection{}, i.negativeTTL, nil
+	}
+
+	userId, _ := fields[i.claims.UserId].(string)
+	// client credentials 토큰에는 sub가 없는 경우가 많다
+	for _, field := range []string{"client_id", "username"} {
+		if userId == "" {
+			userId, _ = fields[field].(string)
+		}
+	}
+	if userId == "" {
+		// active 토큰이므로 inactive로 캐시하지 않는다
+		return introspection{active: true}, 0, nil
+	}
+	roles, _ := stringValues(fields[i.claims.Role])
+	// scope는 공백으로 구분된 문자열이다
+	if len(roles) == 1 {
+		roles = strings.Fields(roles[0])
+	}
+
+	result := introspection{active: true, userId: userId, roles: roles, headers: map[string]string{}}
+	for name, field := range i.headers {
+		if values, ok := stringValues(fields[field]); ok {
+			result.headers[name] = strings.Join(values, ",")
+		}
+	}
+
+	ttl := i.maxTTL
+	if exp, ok := fields["exp"].(float64); ok {
+		ttl = min(ttl, time.Unix(int64(exp), 0).Sub(i.now()))
+	}
+	return result, ttl, nil
+}
+
+func (i *IntrospectAuthProxy) Challenge(err error) string {
+	return bearerChallenge(err)
+}
+
+// InjectedHeaders returns the headers mapped from introspection fields.
+func (i *IntrospectAuthProxy) InjectedHeaders() []string {
+	headers := make([]string, 0, len(i.headers))
+	for header := range i.headers {
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+func (i *IntrospectAuthProxy) GetType() ProxyType {
+	return ProxyType(INTROSPECT)
+}
+
+// tokenCache is a bounded LRU of introspection results keyed by token hash.
+type tokenCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	result  introspection
+	expires time.Time
+}
+
+func newTokenCache(size int) *tokenCache {
+	return &tokenCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (c *tokenCache) get(key string, now time.Time) (introspection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return introspection{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return introspection{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *tokenCache) add(key string, result introspection, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = &cacheEntry{key: key, result: result, expires: expires}
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// introspectionServer is a stand-in RFC 7662 endpoint that knows one token.
+func introspectionServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "gateway" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		response := map[string]interface{}{"active": false}
+		switch r.PostForm.Get("token") {
+		case "opaque-token":
+			response = map[string]interface{}{
+				"active": true,
+				"sub":    "client-1",
+				"scope":  "orders:read orders:write",
+				"tenant": "acme",
+				"exp":    time.Now().Add(time.Hour).Unix(),
+			}
+		case "machine-token":
+			// client credentials 토큰: sub와 scope가 없다
+			response = map[string]interface{}{"active": true, "client_id": "batch-job"}
+		case "anonymous-token":
+			response = map[string]interface{}{"active": true}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestIntrospectAuthProxy(t *testing.T) {
+	var calls atomic.Int32
+	server := introspectionServer(t, &calls)
+	defer server.Close()
+
+	proxy, err := IntrospectAuthConfig{
+		Endpoint:     server.URL,
+		ClientId:     "gateway",
+		ClientSecret: "s3cret",
+		Headers:      map[string]string{"X-Tenant-Id": "tenant"},
+	}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+
+	for range 3 {
+		request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		request.Header.Set("Authorization", "Bearer opaque-token")
+		if err := proxy.Handle(request); err != nil {
+			t.Fatal("Auth handle fail ", err)
+		}
+		if request.Header.Get("X-User-Id") != "client-1" {
+			t.Errorf("X-User-Id 불일치: %q", request.Header.Get("X-User-Id"))
+		}
+		if request.Header.Get("X-User-Role") != "orders:read,orders:write" {
+			t.Errorf("X-User-Role 불일치: %q", request.Header.Get("X-User-Role"))
+		}
+		if request.Header.Get("X-Tenant-Id") != "acme" {
+			t.Errorf("X-Tenant-Id 불일치: %q", request.Header.Get("X-Tenant-Id"))
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("active 결과가 캐시되지 않았습니다. 호출 수: %d", calls.Load())
+	}
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		request.Header.Set("Authorization", "Bearer revoked-token")
+		var authErr *AuthError
+		if err := proxy.Handle(request); !errors.As(err, &authErr) || authErr.Reason != ReasonInvalidToken {
+			t.Errorf("inactive 토큰 결과 불일치: %v", err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("inactive 결과가 캐시되지 않았습니다. 호출 수: %d", calls.Load())
+	}
+
+	// 캐시 만료 후에는 다시 조회한다
+	proxy.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set("Authorization", "Bearer opaque-token")
+	proxy.Handle(request)
+	if calls.Load() != 3 {
+		t.Errorf("만료된 캐시가 사용되었습니다. 호출 수: %d", calls.Load())
+	}
+}
+
+func TestIntrospectEndpointFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := introspectionServer(t, &calls)
+	defer server.Close()
+
+	proxy, err := IntrospectAuthConfig{Endpoint: server.URL, ClientId: "gateway", ClientSecret: "wrong"}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+	request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set("Authorization", "Bearer opaque-token")
+	var authErr *AuthError
+	if err := proxy.Handle(request); !errors.As(err, &authErr) || authErr.Reason != ReasonIntrospectionFailed {
+		t.Errorf("endpoint 오류 결과 불일치: %v", err)
+	}
+	if err := proxy.Handle(request); !Unavailable(err) {
+		t.Errorf("endpoint 오류는 인증 거부가 아닙니다: %v", err)
+	}
+}
+
+func TestIntrospectOptionalFields(t *testing.T) {
+	var calls atomic.Int32
+	server := introspectionServer(t, &calls)
+	defer server.Close()
+
+	proxy, err := IntrospectAuthConfig{Endpoint: server.URL, ClientId: "gateway", ClientSecret: "s3cret"}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set("Authorization", "Bearer machine-token")
+	if err := proxy.Handle(request); err != nil {
+		t.Fatal("sub, scope 없는 active 토큰 거부: ", err)
+	}
+	if request.Header.Get("X-User-Id") != "batch-job" || request.Header.Get("X-User-Role") != "" {
+		t.Errorf("client_id fallback 실패: %q %q", request.Header.Get("X-User-Id"), request.Header.Get("X-User-Role"))
+	}
+
+	// 사용자를 알 수 없는 active 토큰은 거부하되 inactive로 캐시하지 않는다
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "/orders", nil)
+		request.Header.Set("Authorization", "Bearer anonymous-token")
+		var authErr *AuthError
+		if err := proxy.Handle(request); !errors.As(err, &authErr) || authErr.Reason != ReasonMissingClaim {
+			t.Errorf("사용자 없는 토큰 결과 불일치: %v", err)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("사용자 없는 토큰 결과가 캐시되었습니다. 호출 수: %d", calls.Load())
+	}
+}
+
+func TestTokenCacheEviction(t *testing.T) {
+	cache := newTokenCache(2)
+	now := time.Now()
+	expires := now.Add(time.Minute)
+	cache.add("a", introspection{userId: "a"}, expires)
+	cache.add("b", introspection{userId: "b"}, expires)
+	cache.get("a", now)
+	cache.add("c", introspection{userId: "c"}, expires)
+
+	if _, ok := cache.get("b", now); ok {
+		t.Error("가장 오래 사용되지 않은 항목이 제거되지 않았습니다")
+	}
+	if _, ok := cache.get("a", now); !ok {
+		t.Error("최근 사용한 항목이 제거되었습니다")
+	}
+	if _, ok := cache.get("c", expires); ok {
+		t.Error("만료된 항목이 반환되었습니다")
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway-go/internal/logger"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = 5 * time.Minute
+	jwksFetchTimeout   = 5 * time.Second
+	// 알 수 없는 kid로 인한 강제 갱신의 최소 간격
+	minJWKSRefetch = 10 * time.Second
+	maxJWKSSize    = 1 << 20
+)
+
+// JWKS caches the signing keys of a JSON Web Key Set loaded from a URL or
+// a local file, keyed by kid. Keys are refreshed in the background every
+// refresh interval and on demand when a token names an unknown kid.
+type JWKS struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]jwk
+
+	fetchMu     sync.Mutex
+	lastAttempt time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// errUnsupportedKey marks keys of a type or curve the gateway cannot verify.
+// They are skipped so one such key does not invalidate the whole set.
+var errUnsupportedKey = errors.New("unsupported jwk")
+
+type jwk struct {
+	key any
+	alg string
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func NewJWKS(url, file string, refresh time.Duration) (*JWKS, error) {
+	if (url == "") == (file == "") {
+		return nil, errors.New("jwks needs exactly one of jwks-url or jwks-file")
+	}
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	k := &JWKS{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+		keys:    map[string]jwk{},
+		stop:    make(chan struct{}),
+	}
+	// 로컬 파일은 설정 검증 단계에서 바로 읽어 잘못된 파일을 거부한다
+	if file != "" {
+		if err := k.load(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Start loads the key set and keeps refreshing it until Close.
+func (k *JWKS) Start() {
+	go func() {
+		ticker := time.NewTicker(k.refresh)
+		defer ticker.Stop()
+		for {
+			if err := k.load(); err != nil {
+				logger.App.Warn("Failed to refresh JWKS", "source", k.source(), "error", err)
+			}
+			select {
+			case <-k.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (k *JWKS) Close() {
+	k.once.Do(func() {
+		close(k.stop)
+	})
+}
+
+// Key returns the key for kid and the algorithm it is restricted to, if any.
+func (k *JWKS) Key(kid string) (any, string, error) {
+	if key, ok := k.lookup(kid); ok {
+		return key.key, key.alg, nil
+	}
+
+	// 진행 중인 갱신을 기다린 뒤, 키 교체 직후일 수 있으므로 한 번 다시 읽는다
+	k.fetchMu.Lock()
+	if _, ok := k.lookup(kid); !ok && time.Since(k.lastAttempt) >= minJWKSRefetch {
+		if err := k.loadLocked(); err != nil {
+			logger.App.Warn("Failed to refresh JWKS", "source", k.source(), "error", err)
+		}
+	}
+	k.fetchMu.Unlock()
+
+	if key, ok := k.lookup(kid); ok {
+		return key.key, key.alg, nil
+	}
+	return nil, "", fmt.Errorf("unknown kid: %q", kid)
+}
+
+func (k *JWKS) lookup(kid string) (jwk, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *JWKS) source() string {
+	if k.url != "" {
+		return k.url
+	}
+	return k.file
+}
+
+func (k *JWKS) load() error {
+	k.fetchMu.Lock()
+	defer k.fetchMu.Unlock()
+	return k.loadLocked()
+}
+
+// loadLocked fetches the key set. Callers hold k.fetchMu, which also
+// guards lastAttempt.
+func (k *JWKS) loadLocked() error {
+	k.lastAttempt = time.Now()
+
+	data, err := k.read()
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+func (k *JWKS) read() ([]byte, error) {
+	if k.file != "" {
+		return os.ReadFile(k.file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected jwks status: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+func parseJWKS(data []byte) (map[string]jwk, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			logger.App.Debug("Skipping unsupported JWK", "kid", raw.Kid, "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", raw.Kid, err)
+		}
+		keys[raw.Kid] = jwk{key: key, alg: raw.Alg}
+	}
+	return keys, nil
+}
+
+func (j jsonWebKey) publicKey() (any, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("%w: key type %q", errUnsupportedKey, j.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
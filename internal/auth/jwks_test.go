@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type jwksServer struct {
+	mu   sync.Mutex
+	keys []jsonWebKey
+}
+
+func (s *jwksServer) set(keys ...jsonWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, jsonWebKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   encodeBigInt(key.N),
+		E:   encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(t *testing.T, kid string) (*ecdsa.PrivateKey, jsonWebKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   encodeBigInt(key.X),
+		Y:   encodeBigInt(key.Y),
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	claims := jwt.MapClaims{
+		"userId": "testUser",
+		"role":   []string{"USER"},
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal("token create fail ", err)
+	}
+	return signed
+}
+
+func newJwksProxy(t *testing.T, config JwtAuthConfig) JwtAuthProxy {
+	config.AuthHeader = "Authorization"
+	config.Claims = Claims{UserId: "userId", Role: "role"}
+	proxy, err := config.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+	return proxy
+}
+
+func handle(proxy JwtAuthProxy, token string) error {
+	request := http.Request{Header: http.Header{}}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return proxy.Handle(&request)
+}
+
+func TestJwksAuthProxy(t *testing.T) {
+	rsaKey, rsaPublic := rsaJWK(t, "rsa-1")
+	ecKey, ecPublic := ecJWK(t, "ec-1")
+	keys := &jwksServer{}
+	keys.set(rsaPublic, ecPublic)
+	server := httptest.NewServer(keys)
+	defer server.Close()
+
+	proxy := newJwksProxy(t, JwtAuthConfig{JWKSURL: server.URL})
+	proxy.Start()
+	defer proxy.Close()
+
+	if err := handle(proxy, signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey)); err != nil {
+		t.Errorf("RS256 검증 실패: %v", err)
+	}
+	if err := handle(proxy, signToken(t, jwt.SigningMethodES256, "ec-1", ecKey)); err != nil {
+		t.Errorf("ES256 검증 실패: %v", err)
+	}
+	if err := handle(proxy, signToken(t, jwt.SigningMethodRS256, "unknown", rsaKey)); err == nil {
+		t.Error("알 수 없는 kid 허용")
+	}
+	if err := handle(proxy, signToken(t, jwt.SigningMethodRS256, "ec-1", rsaKey)); err == nil {
+		t.Error("다른 키로 서명된 토큰 허용")
+	}
+	if err := handle(proxy, signToken(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"))); err == nil {
+		t.Error("허용되지 않은 알고리즘 허용")
+	}
+}
+
+func TestJwksKeyRotation(t *testing.T) {
+	_, oldPublic := rsaJWK(t, "old")
+	newKey, newPublic := rsaJWK(t, "new")
+	keys := &jwksServer{}
+	keys.set(oldPublic)
+	server := httptest.NewServer(keys)
+	defer server.Close()
+
+	proxy := newJwksProxy(t, JwtAuthConfig{JWKSURL: server.URL, Algorithms: []string{"RS256"}})
+	if _, _, err := proxy.jwks.Key("old"); err != nil {
+		t.Fatal("초기 JWKS 로드 실패 ", err)
+	}
+
+	keys.set(newPublic)
+	proxy.jwks.lastAttempt = proxy.jwks.lastAttempt.Add(-minJWKSRefetch)
+	if err := handle(proxy, signToken(t, jwt.SigningMethodRS256, "new", newKey)); err != nil {
+		t.Errorf("교체된 키 검증 실패: %v", err)
+	}
+	if _, _, err := proxy.jwks.Key("old"); err == nil {
+		t.Error("제거된 키 허용")
+	}
+}
+
+func TestJwksFile(t *testing.T) {
+	key, public := ecJWK(t, "file-1")
+	data, _ := json.Marshal(map[string]any{"keys": []jsonWebKey{public}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := newJwksProxy(t, JwtAuthConfig{JWKSFile: file, Algorithms: []string{"ES256"}})
+	if err := handle(proxy, signToken(t, jwt.SigningMethodES256, "file-1", key)); err != nil {
+		t.Errorf("JWKS 파일 검증 실패: %v", err)
+	}
+
+	if _, err := (JwtAuthConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}).toProxy(); err == nil {
+		t.Error("존재하지 않는 JWKS 파일 허용")
+	}
+	if _, err := (JwtAuthConfig{Secret: "s", Algorithms: []string{"none"}}).toProxy(); err == nil {
+		t.Error("none 알고리즘 허용")
+	}
+}
+
+func TestJwksMixedKeySet(t *testing.T) {
+	key, public := ecJWK(t, "ec-1")
+	data, _ := json.Marshal(map[string]any{"keys": []jsonWebKey{
+		{Kty: "OKP", Kid: "ed-1", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{Kty: "oct", Kid: "hmac-1"},
+		{Kty: "EC", Kid: "ec-secp256k1", Crv: "secp256k1", X: public.X, Y: public.Y},
+		public,
+	}})
+	keys, err := parseJWKS(data)
+	if err != nil {
+		t.Fatalf("지원하지 않는 key 때문에 JWKS 전체 실패: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("지원하지 않는 key 제외 실패: %d", len(keys))
+	}
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	proxy := newJwksProxy(t, JwtAuthConfig{JWKSFile: file, Algorithms: []string{"ES256"}})
+	if err := handle(proxy, signToken(t, jwt.SigningMethodES256, "ec-1", key)); err != nil {
+		t.Errorf("혼합 JWKS 검증 실패: %v", err)
+	}
+
+	// 지원하는 형식의 깨진 key는 여전히 오류
+	broken, _ := json.Marshal(map[string]any{"keys": []jsonWebKey{{Kty: "RSA", Kid: "rsa-1", N: "!", E: "AQAB"}}})
+	if _, err := parseJWKS(broken); err == nil {
+		t.Error("잘못된 RSA key 허용")
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Certificate fields an identity can be taken from.
+const (
+	SourceURI   = "uri"
+	SourceDNS   = "dns"
+	SourceEmail = "email"
+	SourceCN    = "cn"
+)
+
+var defaultIdentitySources = []string{SourceURI, SourceCN}
+
+// MTLSAuthConfig accepts clients whose certificate the TLS listener has
+// verified. The identity is taken from the first source in IdentitySources
+// that the certificate has, so a SPIFFE ID in a URI SAN wins over the CN
+// by default. Every client is given Roles.
+type MTLSAuthConfig struct {
+	IdentitySources []string `yaml:"identity-sources"`
+	Roles           []string `yaml:"roles"`
+}
+
+type MTLSAuthProxy struct {
+	sources []string
+	roles   []string
+}
+
+func (c MTLSAuthConfig) toProxy() (MTLSAuthProxy, error) {
+	if len(c.Roles) == 0 {
+		return MTLSAuthProxy{}, errors.New("mtls-auth needs roles")
+	}
+	sources := c.IdentitySources
+	if len(sources) == 0 {
+		sources = defaultIdentitySources
+	}
+	for _, source := range sources {
+		switch source {
+		case SourceURI, SourceDNS, SourceEmail, SourceCN:
+		default:
+			return MTLSAuthProxy{}, fmt.Errorf("unknown identity source: %q", source)
+		}
+	}
+	return MTLSAuthProxy{sources: sources, roles: c.Roles}, nil
+}
+
+func (m *MTLSAuthProxy) Handle(r *http.Request) error {
+	// VerifiedChains는 listener가 client CA로 검증한 경우에만 채워진다
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return newAuthError(ReasonMissingCredentials, "client certificate is missing", nil)
+	}
+	identity := m.identity(r.TLS.VerifiedChains[0][0])
+	if identity == "" {
+		return newAuthError(ReasonMissingClaim, "client certificate has no identity", nil)
+	}
+
+	updateRequest(identity, m.roles, r)
+	setPeer(r, identity)
+	return nil
+}
+
+func (m *MTLSAuthProxy) identity(cert *x509.Certificate) string {
+	for _, source := range m.sources {
+		switch source {
+		case SourceURI:
+			if len(cert.URIs) > 0 {
+				return cert.URIs[0].String()
+			}
+		case SourceDNS:
+			if len(cert.DNSNames) > 0 {
+				return cert.DNSNames[0]
+			}
+		case SourceEmail:
+			if len(cert.EmailAddresses) > 0 {
+				return cert.EmailAddresses[0]
+			}
+		case SourceCN:
+			if cert.Subject.CommonName != "" {
+				return cert.Subject.CommonName
+			}
+		}
+	}
+	return ""
+}
+
+func (m *MTLSAuthProxy) GetType() ProxyType {
+	return ProxyType(MTLS)
+}
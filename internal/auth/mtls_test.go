@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func clientCertificate(t *testing.T, cn string, uris ...string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.URIs = append(template.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func requestWithCert(cert *x509.Certificate) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/internal", nil)
+	if cert != nil {
+		request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	return request
+}
+
+func TestMTLSAuthProxy(t *testing.T) {
+	proxy, err := MTLSAuthConfig{Roles: []string{"SERVICE"}}.toProxy()
+	if err != nil {
+		t.Fatal("proxy create fail ", err)
+	}
+
+	spiffe := "spiffe://example.org/ns/prod/sa/billing"
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		expected string
+		reason   string
+	}{
+		{"uri san", clientCertificate(t, "billing", spiffe), spiffe, ""},
+		{"common name", clientCertificate(t, "orders"), "orders", ""},
+		{"no identity", clientCertificate(t, ""), "", ReasonMissingClaim},
+		{"no certificate", nil, "", ReasonMissingCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := requestWithCert(tt.cert)
+			err := proxy.Handle(request)
+			if tt.reason != "" {
+				var authErr *AuthError
+				if !errors.As(err, &authErr) || authErr.Reason != tt.reason {
+					t.Fatalf("reason 불일치. 기대값: %s, 실제값: %v", tt.reason, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			identity, _ := IdentityFrom(request)
+			if request.Header.Get("X-User-Id") != tt.expected || identity.Peer != tt.expected {
+				t.Errorf("identity 불일치. 기대값: %s, 실제값: %s / %s", tt.expected, request.Header.Get("X-User-Id"), identity.Peer)
+			}
+		})
+	}
+
+	if _, err := (MTLSAuthConfig{Roles: []string{"SERVICE"}, IdentitySources: []string{"ip"}}).toProxy(); err == nil {
+		t.Error("알 수 없는 identity source가 허용되었습니다")
+	}
+}
+
+func TestAllowIdentities(t *testing.T) {
+	store := Store{
+		"MTLS": &MTLSAuthProxy{sources: defaultIdentitySources, roles: []string{"SERVICE"}},
+		"JWT":  stubProxy{proxyType: "JWT", userId: "alice"},
+	}
+
+	policy := Policy{AllowIdentities: []string{"spiffe://example.org/ns/prod/*", "orders"}}
+	chain := Requirement{Mode: ModeAll, Names: []string{"mtls", "jwt"}}
+
+	tests := []struct {
+		cert    *x509.Certificate
+		allowed bool
+	}{
+		{clientCertificate(t, "billing", "spiffe://example.org/ns/prod/sa/billing"), true},
+		{clientCertificate(t, "billing", "spiffe://example.org/ns/dev/sa/billing"), false},
+		{clientCertificate(t, "orders"), true},
+	}
+	for _, tt := range tests {
+		request := requestWithCert(tt.cert)
+		if err := chain.Authenticate(store, request); err != nil {
+			t.Fatal(err)
+		}
+		identity, _ := IdentityFrom(request)
+		if identity.UserId != "alice" {
+			t.Errorf("후속 provider의 사용자가 적용되지 않았습니다: %s", identity.UserId)
+		}
+		if got := policy.Allows(http.MethodGet, identity); got != tt.allowed {
+			t.Errorf("%s: 인가 결과 불일치. 기대값: %v, 실제값: %v", identity.Peer, tt.allowed, got)
+		}
+	}
+
+	if policy.Allows(http.MethodGet, Identity{UserId: "alice", Roles: []string{"USER"}}) {
+		t.Error("client certificate 없는 요청이 허용되었습니다")
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// Policy authorizes authenticated callers by role. A caller holding any
+// denied role is rejected; when allowed roles are listed the caller must
+// hold at least one of them. Every rule whose methods include the request
+// method is evaluated the same way, in addition to the route level roles.
+// AllowIdentities restricts the client certificate identity; an entry
+// ending in * matches any identity with that prefix.
+type Policy struct {
+	AllowRoles      []string     `yaml:"allow_roles"`
+	DenyRoles       []string     `yaml:"deny_roles"`
+	Rules           []PolicyRule `yaml:"rules"`
+	AllowIdentities []string     `yaml:"allow_identities"`
+}
+
+// PolicyRule applies to the listed methods, or to every method when empty.
+type PolicyRule struct {
+	Methods    []string `yaml:"methods"`
+	AllowRoles []string `yaml:"allow_roles"`
+	DenyRoles  []string `yaml:"deny_roles"`
+}
+
+func (p Policy) IsEmpty() bool {
+	return len(p.AllowRoles) == 0 && len(p.DenyRoles) == 0 && len(p.Rules) == 0 && len(p.AllowIdentities) == 0
+}
+
+func (p Policy) Validate() error {
+	for _, rule := range p.Rules {
+		if len(rule.AllowRoles) == 0 && len(rule.DenyRoles) == 0 {
+			return errors.New("authorization rule needs allow_roles or deny_roles")
+		}
+	}
+	return nil
+}
+
+// Allows reports whether identity may send a request with method.
+func (p Policy) Allows(method string, identity Identity) bool {
+	if len(p.AllowIdentities) > 0 && !matchesIdentity(p.AllowIdentities, identity.Peer) {
+		return false
+	}
+	roles := identity.Roles
+	if !allowed(p.AllowRoles, p.DenyRoles, roles) {
+		return false
+	}
+	for _, rule := range p.Rules {
+		if rule.appliesTo(method) && !allowed(rule.AllowRoles, rule.DenyRoles, roles) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r PolicyRule) appliesTo(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowed(allow, deny, roles []string) bool {
+	if containsAny(deny, roles) {
+		return false
+	}
+	return len(allow) == 0 || containsAny(allow, roles)
+}
+
+func containsAny(expected, roles []string) bool {
+	for _, e := range expected {
+		for _, role := range roles {
+			if e == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesIdentity(patterns []string, peer string) bool {
+	if peer == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(peer, prefix) {
+				return true
+			}
+		} else if pattern == peer {
+			return true
+		}
+	}
+	return false
+}
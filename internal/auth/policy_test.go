@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{
+		AllowRoles: []string{"USER", "ADMIN"},
+		DenyRoles:  []string{"BANNED"},
+		Rules: []PolicyRule{
+			{Methods: []string{"DELETE"}, AllowRoles: []string{"ADMIN"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		roles   []string
+		allowed bool
+	}{
+		{"user can read", http.MethodGet, []string{"USER"}, true},
+		{"no roles", http.MethodGet, nil, false},
+		{"unknown role", http.MethodGet, []string{"GUEST"}, false},
+		{"denied role wins", http.MethodGet, []string{"USER", "BANNED"}, false},
+		{"user cannot delete", http.MethodDelete, []string{"USER"}, false},
+		{"admin can delete", "delete", []string{"ADMIN"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allows(tt.method, Identity{Roles: tt.roles}); got != tt.allowed {
+				t.Errorf("인가 결과 불일치. 기대값: %v, 실제값: %v", tt.allowed, got)
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{Rules: []PolicyRule{{Methods: []string{"GET"}}}}).Validate(); err == nil {
+		t.Error("빈 rule 검증 실패")
+	}
+}
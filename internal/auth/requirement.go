@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Mode string
+
+const (
+	// ModeAny accepts a request that any one of the providers accepts.
+	ModeAny Mode = "any"
+	// ModeAll accepts a request only if every provider accepts it.
+	ModeAll Mode = "all"
+)
+
+// Requirement is the auth a route asks for. In config.yml it is either a
+// single provider name, a list of names (any), or a map with one key, any
+// or all, holding the names:
+//
+//	auth: jwt
+//	auth: [jwt, api_key]
+//	auth:
+//	  all: [mtls, jwt]
+type Requirement struct {
+	Mode  Mode
+	Names []string
+}
+
+func (q *Requirement) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var name string
+		if err := node.Decode(&name); err != nil {
+			return err
+		}
+		*q = Requirement{}
+		if name != "" && !strings.EqualFold(name, string(NONE)) {
+			*q = Requirement{Mode: ModeAny, Names: []string{name}}
+		}
+		return nil
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*q = Requirement{Mode: ModeAny, Names: names}
+		return nil
+	case yaml.MappingNode:
+		var modes map[Mode][]string
+		if err := node.Decode(&modes); err != nil {
+			return err
+		}
+		if len(modes) != 1 {
+			return errors.New("auth must have exactly one of any or all")
+		}
+		for mode, names := range modes {
+			*q = Requirement{Mode: mode, Names: names}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid auth at line %d", node.Line)
+	}
+}
+
+func (q Requirement) IsEmpty() bool {
+	return len(q.Names) == 0
+}
+
+// Validate checks that every provider named by q is configured in store.
+func (q Requirement) Validate(store Store) error {
+	if q.Mode != ModeAny && q.Mode != ModeAll {
+		return fmt.Errorf("unknown auth mode: %q", q.Mode)
+	}
+	if q.IsEmpty() {
+		return fmt.Errorf("auth %s needs at least one provider", q.Mode)
+	}
+	seen := map[string]bool{}
+	for _, name := range q.Names {
+		key := strings.ToUpper(name)
+		if seen[key] {
+			return fmt.Errorf("duplicate auth provider: %q", name)
+		}
+		seen[key] = true
+		if store.Get(name) == nil {
+			return fmt.Errorf("AuthProxy is not setting %s", name)
+		}
+	}
+	return nil
+}
+
+// Uses reports whether q names a provider of type t in store.
+func (q Requirement) Uses(store Store, t AuthType) bool {
+	for _, name := range q.Names {
+		if proxy := store.Get(name); proxy != nil && proxy.GetType() == ProxyType(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate runs the providers of q from store against r. The store is
+// the one the route was validated against, not the active one, so a route
+// and its providers always come from the same config.
+// With ModeAny the first provider that accepts wins; with ModeAll every
+// provider must accept and later ones override the identity of earlier
+// ones. A provider missing from the store rejects the request.
+func (q Requirement) Authenticate(store Store, r *http.Request) error {
+	var failure error
+	for _, name := range q.Names {
+		proxy := store.Get(name)
+		if proxy == nil {
+			return withProvider(newAuthError(ReasonInvalidCredentials, fmt.Sprintf("auth provider %s is not configured", name), nil), name)
+		}
+		err := withProvider(proxy.Handle(r), name)
+		if q.Mode == ModeAll {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err == nil {
+			return nil
+		}
+		// 자격 증명을 제시한 provider의 실패를 우선 보고한다
+		if failure == nil || isMissingCredentials(failure) {
+			failure = err
+		}
+	}
+	return failure
+}
+
+// Challenges returns the WWW-Authenticate values of the providers of q for
+// the failure err returned by Authenticate.
+func (q Requirement) Challenges(store Store, err error) []string {
+	var challenges []string
+	for _, name := range q.Names {
+		if challenger, ok := store.Get(name).(Challenger); ok {
+			challenges = append(challenges, challenger.Challenge(err))
+		}
+	}
+	return challenges
+}
+
+func (q Requirement) String() string {
+	if len(q.Names) == 1 {
+		return q.Names[0]
+	}
+	return string(q.Mode) + "(" + strings.Join(q.Names, ",") + ")"
+}
+
+func isMissingCredentials(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && authErr.Reason == ReasonMissingCredentials
+}
+
+func withProvider(err error, name string) error {
+	if err == nil {
+		return nil
+	}
+	authErr, ok := err.(*AuthError)
+	if !ok {
+		return &AuthError{Reason: ReasonInvalidCredentials, Message: "authentication failed", Provider: strings.ToLower(name), Err: err}
+	}
+	if authErr.Provider == "" {
+		authErr.Provider = strings.ToLower(name)
+	}
+	return authErr
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type stubProxy struct {
+	proxyType ProxyType
+	userId    string
+	reason    string
+}
+
+func (s stubProxy) Handle(r *http.Request) error {
+	if s.reason != "" {
+		return newAuthError(s.reason, string(s.proxyType)+" rejected", nil)
+	}
+	updateRequest(s.userId, []string{"USER"}, r)
+	return nil
+}
+
+func (s stubProxy) GetType() ProxyType {
+	return s.proxyType
+}
+
+func TestRequirementUnmarshal(t *testing.T) {
+	tests := []struct {
+		yml      string
+		expected Requirement
+	}{
+		{"auth: jwt", Requirement{Mode: ModeAny, Names: []string{"jwt"}}},
+		{"auth: none", Requirement{}},
+		{"auth: [jwt, api_key]", Requirement{Mode: ModeAny, Names: []string{"jwt", "api_key"}}},
+		{"auth:\n  all: [mtls, jwt]", Requirement{Mode: ModeAll, Names: []string{"mtls", "jwt"}}},
+	}
+	for _, tt := range tests {
+		var config struct {
+			Auth Requirement `yaml:"auth"`
+		}
+		if err := yaml.Unmarshal([]byte(tt.yml), &config); err != nil {
+			t.Fatalf("%q: %v", tt.yml, err)
+		}
+		if config.Auth.String() != tt.expected.String() || config.Auth.Mode != tt.expected.Mode {
+			t.Errorf("%q: 기대값: %v, 실제값: %v", tt.yml, tt.expected, config.Auth)
+		}
+	}
+
+	var config struct {
+		Auth Requirement `yaml:"auth"`
+	}
+	if err := yaml.Unmarshal([]byte("auth:\n  any: [jwt]\n  all: [basic]"), &config); err == nil {
+		t.Error("any와 all을 함께 쓴 설정이 허용되었습니다")
+	}
+}
+
+func TestRequirementValidate(t *testing.T) {
+	store := Store{"JWT": stubProxy{proxyType: "JWT"}, "API_KEY": stubProxy{proxyType: "API_KEY"}}
+
+	valid := []Requirement{
+		{Mode: ModeAny, Names: []string{"jwt", "api_key"}},
+		{Mode: ModeAll, Names: []string{"JWT"}},
+	}
+	for _, q := range valid {
+		if err := q.Validate(store); err != nil {
+			t.Errorf("%v: %v", q, err)
+		}
+	}
+
+	invalid := []Requirement{
+		{Mode: ModeAny, Names: []string{"jwt", "jwtt"}},
+		{Mode: ModeAll, Names: []string{"jwt", "JWT"}},
+		{Mode: ModeAll},
+		{Mode: "either", Names: []string{"jwt"}},
+	}
+	for _, q := range invalid {
+		if err := q.Validate(store); err == nil {
+			t.Errorf("잘못된 auth 설정이 허용되었습니다: %v", q)
+		}
+	}
+}
+
+func TestRequirementAuthenticate(t *testing.T) {
+	store := Store{
+		"JWT":     stubProxy{proxyType: "JWT", reason: ReasonMissingCredentials},
+		"API_KEY": stubProxy{proxyType: "API_KEY", userId: "billing"},
+		"BASIC":   stubProxy{proxyType: "BASIC", reason: ReasonInvalidCredentials},
+		"MTLS":    stubProxy{proxyType: "MTLS", userId: "cert"},
+	}
+
+	tests := []struct {
+		name     string
+		q        Requirement
+		reason   string
+		expected string
+	}{
+		{"any accepts second", Requirement{Mode: ModeAny, Names: []string{"jwt", "api_key"}}, "", "billing"},
+		{"any reports presented credentials", Requirement{Mode: ModeAny, Names: []string{"jwt", "basic"}}, ReasonInvalidCredentials, ""},
+		{"all accepts", Requirement{Mode: ModeAll, Names: []string{"mtls", "api_key"}}, "", "billing"},
+		{"all rejects", Requirement{Mode: ModeAll, Names: []string{"mtls", "jwt"}}, ReasonMissingCredentials, ""},
+		{"unknown provider", Requirement{Mode: ModeAny, Names: []string{"missing"}}, ReasonInvalidCredentials, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "/", nil)
+			err := tt.q.Authenticate(store, request)
+			if tt.reason != "" {
+				var authErr *AuthError
+				if !errors.As(err, &authErr) || authErr.Reason != tt.reason {
+					t.Fatalf("reason 불일치. 기대값: %s, 실제값: %v", tt.reason, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if request.Header.Get("X-User-Id") != tt.expected {
+				t.Errorf("X-User-Id 불일치. 기대값: %s, 실제값: %s", tt.expected, request.Header.Get("X-User-Id"))
+			}
+		})
+	}
+}
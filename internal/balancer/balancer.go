@@ -0,0 +1,172 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Strategy string
+
+const (
+	RoundRobin         Strategy = "round-robin"
+	WeightedRoundRobin Strategy = "weighted-round-robin"
+	LeastConnections   Strategy = "least-connections"
+	RandomTwoChoices   Strategy = "random-two-choices"
+)
+
+func ParseStrategy(value string) (Strategy, error) {
+	switch Strategy(strings.ToLower(value)) {
+	case "", WeightedRoundRobin:
+		return WeightedRoundRobin, nil
+	case RoundRobin:
+		return RoundRobin, nil
+	case LeastConnections:
+		return LeastConnections, nil
+	case RandomTwoChoices:
+		return RandomTwoChoices, nil
+	default:
+		return "", fmt.Errorf("unknown balancing strategy: %q", value)
+	}
+}
+
+// Balancer picks the target for the next request, skipping targets that
+// are not available. Next returns nil when no target is available.
+// Implementations are safe for concurrent use.
+type Balancer interface {
+	Next() *Target
+	Targets() []*Target
+}
+
+func New(strategy Strategy, targets []*Target) (Balancer, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("balancer needs at least one target")
+	}
+	switch strategy {
+	case RoundRobin:
+		return &roundRobin{targets: targets}, nil
+	case WeightedRoundRobin:
+		return newWeightedRoundRobin(targets), nil
+	case LeastConnections:
+		return &leastConnections{targets: targets}, nil
+	case RandomTwoChoices:
+		return &randomTwoChoices{targets: targets}, nil
+	default:
+		return nil, fmt.Errorf("unknown balancing strategy: %q", strategy)
+	}
+}
+
+type roundRobin struct {
+	targets []*Target
+	next    atomic.Uint64
+}
+
+func (b *roundRobin) Next() *Target {
+	n := b.next.Add(1) - 1
+	size := uint64(len(b.targets))
+	for i := uint64(0); i < size; i++ {
+		target := b.targets[(n+i)%size]
+		if target.Available() {
+			return target
+		}
+	}
+	return nil
+}
+
+func (b *roundRobin) Targets() []*Target {
+	return b.targets
+}
+
+// weightedRoundRobin is the smooth weighted round-robin used by nginx,
+// which spreads picks of heavy targets instead of sending them in bursts.
+type weightedRoundRobin struct {
+	mu      sync.Mutex
+	targets []*Target
+	current []int
+}
+
+func newWeightedRoundRobin(targets []*Target) *weightedRoundRobin {
+	return &weightedRoundRobin{
+		targets: targets,
+		current: make([]int, len(targets)),
+	}
+}
+
+func (b *weightedRoundRobin) Next() *Target {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	best := -1
+	total := 0
+	for i, t := range b.targets {
+		if !t.Available() {
+			continue
+		}
+		b.current[i] += t.Weight
+		total += t.Weight
+		if best == -1 || b.current[i] > b.current[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	b.current[best] -= total
+	return b.targets[best]
+}
+
+func (b *weightedRoundRobin) Targets() []*Target {
+	return b.targets
+}
+
+type leastConnections struct {
+	targets []*Target
+}
+
+func (b *leastConnections) Next() *Target {
+	var best *Target
+	for _, t := range b.targets {
+		if !t.Available() {
+			continue
+		}
+		if best == nil || lessLoaded(t, best) {
+			best = t
+		}
+	}
+	return best
+}
+
+func (b *leastConnections) Targets() []*Target {
+	return b.targets
+}
+
+type randomTwoChoices struct {
+	targets []*Target
+}
+
+func (b *randomTwoChoices) Next() *Target {
+	targets := available(b.targets)
+	n := len(targets)
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return targets[0]
+	}
+	i := rand.IntN(n)
+	j := rand.IntN(n - 1)
+	if j >= i {
+		j++
+	}
+	if lessLoaded(targets[j], targets[i]) {
+		return targets[j]
+	}
+	return targets[i]
+}
+
+func (b *randomTwoChoices) Targets() []*Target {
+	return b.targets
+}
@@ -0,0 +1,197 @@
+package balancer
+
+import (
+	"gateway-go/internal/breaker"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTargets(weights ...int) []*Target {
+	targets := make([]*Target, len(weights))
+	for i, w := range weights {
+		targets[i] = NewTarget(string(rune('a'+i)), w)
+	}
+	return targets
+}
+
+func TestRoundRobin(t *testing.T) {
+	b, err := New(RoundRobin, newTargets(1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for i := 0; i < 6; i++ {
+		got += b.Next().URL
+	}
+	if got != "abcabc" {
+		t.Errorf("round-robin 순서 불일치: %s", got)
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	b, err := New(WeightedRoundRobin, newTargets(5, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for i := 0; i < 7; i++ {
+		got += b.Next().URL
+	}
+	if got != "aabacaa" {
+		t.Errorf("smooth weighted round-robin 순서 불일치: %s", got)
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	targets := newTargets(1, 1, 2)
+	b, err := New(LeastConnections, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets[0].Acquire()
+	targets[1].Acquire()
+	targets[2].Acquire()
+	targets[2].Acquire()
+	targets[0].Release()
+
+	if got := b.Next(); got != targets[0] {
+		t.Errorf("least-connections 선택 실패: %s", got.URL)
+	}
+}
+
+func TestRandomTwoChoices(t *testing.T) {
+	targets := newTargets(1, 1)
+	b, err := New(RandomTwoChoices, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets[0].Acquire()
+	for i := 0; i < 10; i++ {
+		if got := b.Next(); got != targets[1] {
+			t.Fatalf("random-two-choices 선택 실패: %s", got.URL)
+		}
+	}
+}
+
+func TestConcurrentAcquireRelease(t *testing.T) {
+	targets := newTargets(1, 1, 1)
+	b, err := New(LeastConnections, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			target := b.Next()
+			target.Acquire()
+			target.Release()
+		}()
+	}
+	wg.Wait()
+
+	for _, target := range targets {
+		if target.Active() != 0 {
+			t.Errorf("연결 수 불일치: %s=%d", target.URL, target.Active())
+		}
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	if s, err := ParseStrategy(""); err != nil || s != WeightedRoundRobin {
+		t.Errorf("기본 전략 불일치: %s %v", s, err)
+	}
+	if _, err := ParseStrategy("unknown"); err == nil {
+		t.Errorf("알 수 없는 전략 검증 실패")
+	}
+}
+
+func TestSkipUnavailableTargets(t *testing.T) {
+	strategies := []Strategy{RoundRobin, WeightedRoundRobin, LeastConnections, RandomTwoChoices}
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			targets := newTargets(1, 1, 1)
+			b, err := New(strategy, targets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			targets[0].SetDown(true)
+			targets[2].SetEjection(Ejection{MaxFailures: 1, Duration: time.Minute})
+			targets[2].Observe(false, 0)
+
+			for i := 0; i < 6; i++ {
+				if got := b.Next(); got != targets[1] {
+					t.Fatalf("사용 불가 target 선택: %v", got)
+				}
+			}
+
+			targets[1].SetDown(true)
+			if got := b.Next(); got != nil {
+				t.Errorf("모든 target이 불가할 때 nil 이어야 함: %s", got.URL)
+			}
+		})
+	}
+}
+
+func TestSkipRefusingCircuit(t *testing.T) {
+	strategies := []Strategy{RoundRobin, WeightedRoundRobin, LeastConnections, RandomTwoChoices}
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			targets := newTargets(1, 1)
+			b, err := New(strategy, targets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			targets[0].SetBreaker(breaker.New("a", breaker.Config{MinRequests: 1, OpenDuration: 10 * time.Millisecond}))
+			targets[0].Observe(false, 0)
+
+			time.Sleep(20 * time.Millisecond)
+			if !targets[0].Allow() {
+				t.Fatal("half-open probe 거부")
+			}
+			// probe slot이 찬 half-open target은 건너뛴다
+			for i := 0; i < 6; i++ {
+				if got := b.Next(); got != targets[1] {
+					t.Fatalf("breaker가 거부할 target 선택: %v", got)
+				}
+			}
+
+			targets[0].Abandon()
+			if !targets[0].Available() {
+				t.Errorf("probe slot 반환 후 사용 불가: %s", targets[0].Circuit())
+			}
+		})
+	}
+}
+
+func TestPassiveEjection(t *testing.T) {
+	target := NewTarget("a", 1)
+	target.SetEjection(Ejection{MaxFailures: 3, Duration: 20 * time.Millisecond})
+
+	target.Observe(false, 0)
+	target.Observe(false, 0)
+	target.Observe(true, 0)
+	target.Observe(false, 0)
+	if target.Observe(false, 0) {
+		t.Fatal("연속 실패가 아닌데 eject 됨")
+	}
+	if !target.Observe(false, 0) {
+		t.Fatal("연속 실패 후 eject 되지 않음")
+	}
+	if target.Status() != StatusEjected {
+		t.Errorf("상태 불일치: %s", target.Status())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !target.Available() {
+		t.Errorf("eject 기간 이후 복구되지 않음: %s", target.Status())
+	}
+}
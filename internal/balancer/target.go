@@ -0,0 +1,163 @@
+package balancer
+
+import (
+	"gateway-go/internal/breaker"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+	StatusEjected   = "ejected"
+	StatusOpen      = "circuit-open"
+)
+
+// Ejection configures passive health checking: after MaxFailures consecutive
+// failed requests the target is skipped for Duration.
+// A zero MaxFailures disables passive ejection.
+type Ejection struct {
+	MaxFailures int
+	Duration    time.Duration
+}
+
+// Target is a single upstream endpoint a route can forward to.
+type Target struct {
+	URL    string
+	Weight int
+
+	ejection Ejection
+	breaker  *breaker.Breaker
+
+	active       atomic.Int64
+	down         atomic.Bool
+	failures     atomic.Int64
+	ejectedUntil atomic.Int64
+}
+
+func NewTarget(url string, weight int) *Target {
+	if weight <= 0 {
+		weight = 1
+	}
+	return &Target{
+		URL:    url,
+		Weight: weight,
+	}
+}
+
+// SetEjection enables passive ejection. It must be called before the target
+// receives traffic.
+func (t *Target) SetEjection(ejection Ejection) {
+	t.ejection = ejection
+}
+
+// SetBreaker attaches a circuit breaker. It must be called before the
+// target receives traffic.
+func (t *Target) SetBreaker(b *breaker.Breaker) {
+	t.breaker = b
+}
+
+// Circuit returns the circuit breaker state, or "" without a breaker.
+func (t *Target) Circuit() string {
+	if t.breaker == nil {
+		return ""
+	}
+	return t.breaker.State().String()
+}
+
+// Allow asks the circuit breaker whether a request may be sent. Every
+// allowed request must be followed by Observe or Abandon.
+func (t *Target) Allow() bool {
+	return t.breaker == nil || t.breaker.Allow()
+}
+
+// Abandon is called instead of Observe when the outcome of an allowed
+// request is unknown.
+func (t *Target) Abandon() {
+	if t.breaker != nil {
+		t.breaker.Release()
+	}
+}
+
+// Acquire marks a request as in flight on the target.
+func (t *Target) Acquire() {
+	t.active.Add(1)
+}
+
+// Release must be called once for every Acquire when the request completes.
+func (t *Target) Release() {
+	t.active.Add(-1)
+}
+
+// Active returns the number of requests currently in flight.
+func (t *Target) Active() int64 {
+	return t.active.Load()
+}
+
+// Available reports whether the target may receive traffic: it is healthy
+// and its circuit breaker, if any, would admit a request. A half-open
+// target whose probe slots are taken is not available.
+func (t *Target) Available() bool {
+	return t.Status() == StatusHealthy && !t.CircuitRefuses()
+}
+
+// CircuitRefuses reports whether the circuit breaker would refuse a request
+// right now.
+func (t *Target) CircuitRefuses() bool {
+	return t.breaker != nil && !t.breaker.Admits()
+}
+
+func (t *Target) Status() string {
+	if t.down.Load() {
+		return StatusUnhealthy
+	}
+	if time.Now().UnixNano() < t.ejectedUntil.Load() {
+		return StatusEjected
+	}
+	if t.breaker != nil && t.breaker.State() == breaker.Open {
+		return StatusOpen
+	}
+	return StatusHealthy
+}
+
+// SetDown records the result of active health checking and reports
+// whether the state changed.
+func (t *Target) SetDown(down bool) bool {
+	return t.down.Swap(down) != down
+}
+
+// Observe records the outcome of a proxied request and reports whether
+// the target was ejected because of it.
+func (t *Target) Observe(success bool, latency time.Duration) bool {
+	if t.breaker != nil {
+		t.breaker.Record(success, latency)
+	}
+	if success {
+		t.failures.Store(0)
+		return false
+	}
+	if t.ejection.MaxFailures <= 0 {
+		return false
+	}
+	if t.failures.Add(1) < int64(t.ejection.MaxFailures) {
+		return false
+	}
+	t.failures.Store(0)
+	t.ejectedUntil.Store(time.Now().Add(t.ejection.Duration).UnixNano())
+	return true
+}
+
+// lessLoaded compares in-flight requests relative to weight.
+func lessLoaded(a, b *Target) bool {
+	return a.Active()*int64(b.Weight) < b.Active()*int64(a.Weight)
+}
+
+func available(targets []*Target) []*Target {
+	result := make([]*Target, 0, len(targets))
+	for _, t := range targets {
+		if t.Available() {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,227 @@
+package breaker
+
+import (
+	"errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+const (
+	defaultErrorRate        = 0.5
+	defaultMinRequests      = 10
+	defaultWindow           = 10 * time.Second
+	defaultOpenDuration     = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// Config is the per-route circuit_breaker block in config.yml.
+// A request counts as failed when the upstream returns 5xx, the connection
+// fails, or it takes longer than LatencyThreshold (if set).
+type Config struct {
+	ErrorRate        float64       `yaml:"error_rate"`
+	MinRequests      int           `yaml:"min_requests"`
+	Window           time.Duration `yaml:"window"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+	OpenDuration     time.Duration `yaml:"open_duration"`
+	HalfOpenRequests int           `yaml:"half_open_requests"`
+}
+
+func (c Config) Validate() error {
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return errors.New("circuit breaker error_rate must be between 0 and 1")
+	}
+	if c.MinRequests < 0 || c.HalfOpenRequests < 0 {
+		return errors.New("circuit breaker request counts must not be negative")
+	}
+	if c.Window < 0 || c.LatencyThreshold < 0 || c.OpenDuration < 0 {
+		return errors.New("circuit breaker durations must not be negative")
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.ErrorRate == 0 {
+		c.ErrorRate = defaultErrorRate
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = defaultMinRequests
+	}
+	if c.Window == 0 {
+		c.Window = defaultWindow
+	}
+	if c.OpenDuration == 0 {
+		c.OpenDuration = defaultOpenDuration
+	}
+	if c.HalfOpenRequests == 0 {
+		c.HalfOpenRequests = defaultHalfOpenRequests
+	}
+	return c
+}
+
+// Breaker is a circuit breaker for a single upstream target.
+// Closed lets every request through, Open rejects requests until the
+// cool-down has passed, and HalfOpen lets a few probe requests through
+// to decide whether to close again.
+type Breaker struct {
+	name   string
+	config Config
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+	now         func() time.Time
+}
+
+func New(name string, config Config) *Breaker {
+	b := &Breaker{
+		name:   name,
+		config: config.withDefaults(),
+		now:    time.Now,
+	}
+	b.windowStart = b.now()
+	return b
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// Allow reports whether a request may be sent. In HalfOpen only
+// HalfOpenRequests probes are admitted until their results are recorded.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case Open:
+		return false
+	case HalfOpen:
+		if b.probes >= b.config.HalfOpenRequests {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// Admits reports whether Allow would admit a request now, without taking a
+// probe slot, so balancers can skip targets that would refuse.
+func (b *Breaker) Admits() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case Open:
+		return false
+	case HalfOpen:
+		return b.probes < b.config.HalfOpenRequests
+	}
+	return true
+}
+
+// Record reports the outcome of a request admitted by Allow.
+func (b *Breaker) Record(success bool, latency time.Duration) {
+	if b.config.LatencyThreshold > 0 && latency > b.config.LatencyThreshold {
+		success = false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case HalfOpen:
+		b.probes = max(0, b.probes-1)
+		if !success {
+			b.transition(Open)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenRequests {
+			b.transition(Closed)
+		}
+	case Closed:
+		b.requests++
+		if !success {
+			b.failures++
+		}
+		if b.requests >= b.config.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.config.ErrorRate {
+			b.transition(Open)
+		}
+	}
+}
+
+// Release gives back a probe slot for a request admitted by Allow whose
+// outcome is unknown, e.g. because the client went away.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.probes = max(0, b.probes-1)
+	}
+}
+
+// advance moves Open to HalfOpen after the cool-down and rolls the
+// Closed counting window. Callers hold b.mu.
+func (b *Breaker) advance() {
+	now := b.now()
+	switch b.state {
+	case Open:
+		if now.Sub(b.openedAt) >= b.config.OpenDuration {
+			b.transition(HalfOpen)
+		}
+	case Closed:
+		if now.Sub(b.windowStart) >= b.config.Window {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+	}
+}
+
+func (b *Breaker) transition(to State) {
+	from := b.state
+	b.state = to
+	b.probes = 0
+	b.successes = 0
+	b.requests = 0
+	b.failures = 0
+	b.windowStart = b.now()
+	if to == Open {
+		b.openedAt = b.now()
+	}
+	logger.App.Warn("Circuit breaker state changed", "target", b.name, "from", from.String(), "to", to.String())
+	metrics.CircuitTransitions.With(b.name, to.String()).Inc()
+}
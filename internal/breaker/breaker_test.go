@@ -0,0 +1,133 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreaker(config Config) (*Breaker, *fakeClock) {
+	b := New("test", config)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	b.now = clock.Now
+	b.windowStart = clock.now
+	return b, clock
+}
+
+func TestBreakerOpensOnErrorRate(t *testing.T) {
+	b, clock := newTestBreaker(Config{ErrorRate: 0.5, MinRequests: 4, OpenDuration: time.Second})
+
+	b.Record(true, 0)
+	b.Record(false, 0)
+	b.Record(true, 0)
+	if b.State() != Closed {
+		t.Fatal("최소 요청 수 전에 open 됨")
+	}
+	b.Record(false, 0)
+	if b.State() != Open {
+		t.Fatalf("에러율 초과 후 open 실패: %s", b.State())
+	}
+	if b.Allow() {
+		t.Fatal("open 상태에서 요청 허용")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if b.State() != HalfOpen {
+		t.Fatalf("cool-down 후 half-open 전환 실패: %s", b.State())
+	}
+	if !b.Allow() {
+		t.Fatal("half-open probe 거부")
+	}
+	if b.Allow() {
+		t.Fatal("half-open probe 수 제한 실패")
+	}
+	b.Record(true, 0)
+	if b.State() != Closed {
+		t.Errorf("probe 성공 후 close 실패: %s", b.State())
+	}
+}
+
+func TestBreakerReopensOnProbeFailure(t *testing.T) {
+	b, clock := newTestBreaker(Config{MinRequests: 1, OpenDuration: time.Second})
+	b.Record(false, 0)
+
+	clock.now = clock.now.Add(time.Second)
+	if !b.Allow() {
+		t.Fatal("half-open probe 거부")
+	}
+	b.Record(false, 0)
+	if b.State() != Open {
+		t.Errorf("probe 실패 후 open 실패: %s", b.State())
+	}
+}
+
+func TestBreakerLatencyThreshold(t *testing.T) {
+	b, _ := newTestBreaker(Config{MinRequests: 2, ErrorRate: 1, LatencyThreshold: 100 * time.Millisecond})
+
+	b.Record(true, 200*time.Millisecond)
+	b.Record(true, time.Second)
+	if b.State() != Open {
+		t.Errorf("지연 임계치 초과 후 open 실패: %s", b.State())
+	}
+}
+
+func TestBreakerWindowReset(t *testing.T) {
+	b, clock := newTestBreaker(Config{MinRequests: 2, ErrorRate: 1, Window: time.Second})
+
+	b.Record(false, 0)
+	clock.now = clock.now.Add(time.Second)
+	b.Record(false, 0)
+	if b.State() != Closed {
+		t.Errorf("window 초기화 실패: %s", b.State())
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	b, clock := newTestBreaker(Config{MinRequests: 1, OpenDuration: time.Second})
+	b.Record(false, 0)
+	clock.now = clock.now.Add(time.Second)
+
+	if !b.Allow() {
+		t.Fatal("half-open probe 거부")
+	}
+	b.Release()
+	if !b.Allow() {
+		t.Error("release 후 probe 재허용 실패")
+	}
+}
+
+func TestBreakerAdmits(t *testing.T) {
+	b, clock := newTestBreaker(Config{MinRequests: 1, OpenDuration: time.Second})
+	if !b.Admits() {
+		t.Fatal("closed 상태에서 요청 거부")
+	}
+	b.Record(false, 0)
+	if b.Admits() {
+		t.Fatal("open 상태에서 요청 허용")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if !b.Admits() || !b.Admits() {
+		t.Fatal("Admits가 probe slot을 차지함")
+	}
+	b.Allow()
+	if b.Admits() {
+		t.Error("probe slot이 찬 half-open 상태에서 요청 허용")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Config{ErrorRate: 1.5}).Validate(); err == nil {
+		t.Error("error_rate 검증 실패")
+	}
+	if err := (Config{OpenDuration: -time.Second}).Validate(); err == nil {
+		t.Error("duration 검증 실패")
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"gateway-go/internal/util"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Watcher polls files in the config directory and reports when any of
+// them was modified, created or removed.
+type Watcher struct {
+	interval  time.Duration
+	fileNames []string
+	modTimes  map[string]time.Time
+}
+
+func NewWatcher(interval time.Duration, fileNames ...string) *Watcher {
+	w := &Watcher{
+		interval:  interval,
+		fileNames: fileNames,
+		modTimes:  map[string]time.Time{},
+	}
+	w.changed()
+	return w
+}
+
+// Run calls onChange after every detected change until ctx is done.
+func (w *Watcher) Run(ctx context.Context, onChange func()) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.changed() {
+				onChange()
+			}
+		}
+	}
+}
+
+func (w *Watcher) changed() bool {
+	dir, err := util.GetRootDir()
+	if err != nil {
+		return false
+	}
+
+	changed := false
+	for _, name := range w.fileNames {
+		var modTime time.Time
+		if info, err := os.Stat(filepath.Join(dir, configDirName, name)); err == nil {
+			modTime = info.ModTime()
+		}
+		if !modTime.Equal(w.modTimes[name]) {
+			w.modTimes[name] = modTime
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("APP_ROOT_DIR", dir)
+	if err := os.Mkdir(filepath.Join(dir, configDirName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, configDirName, "config.yml")
+	if err := os.WriteFile(file, []byte("routes: []"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWatcher(time.Millisecond, "config.yml")
+	if w.changed() {
+		t.Fatal("변경이 없는데 변경 감지됨")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Error("파일 수정 감지 실패")
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Error("파일 삭제 감지 실패")
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"gateway-go/internal/balancer"
+	"gateway-go/internal/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckInterval      = 10 * time.Second
+	defaultCheckTimeout       = 2 * time.Second
+	defaultHealthyThreshold   = 2
+	defaultUnhealthyThreshold = 3
+	defaultEjectDuration      = 30 * time.Second
+)
+
+// HealthCheck is the per-route health_check block in config.yml.
+type HealthCheck struct {
+	Active  *ActiveCheck  `yaml:"active"`
+	Passive *PassiveCheck `yaml:"passive"`
+}
+
+// ActiveCheck periodically probes every target of a route.
+// ExpectedStatus of 0 accepts any 2xx response.
+type ActiveCheck struct {
+	Path               string        `yaml:"path"`
+	ExpectedStatus     int           `yaml:"expected_status"`
+	Interval           time.Duration `yaml:"interval"`
+	Timeout            time.Duration `yaml:"timeout"`
+	HealthyThreshold   int           `yaml:"healthy_threshold"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold"`
+}
+
+// PassiveCheck ejects a target after consecutive 5xx responses or
+// connection errors observed while proxying.
+type PassiveCheck struct {
+	MaxFailures   int           `yaml:"max_failures"`
+	EjectDuration time.Duration `yaml:"eject_duration"`
+}
+
+func (p PassiveCheck) Ejection() balancer.Ejection {
+	duration := p.EjectDuration
+	if duration <= 0 {
+		duration = defaultEjectDuration
+	}
+	return balancer.Ejection{
+		MaxFailures: p.MaxFailures,
+		Duration:    duration,
+	}
+}
+
+func (a ActiveCheck) withDefaults() ActiveCheck {
+	if a.Interval <= 0 {
+		a.Interval = defaultCheckInterval
+	}
+	if a.Timeout <= 0 {
+		a.Timeout = defaultCheckTimeout
+	}
+	if a.HealthyThreshold <= 0 {
+		a.HealthyThreshold = defaultHealthyThreshold
+	}
+	if a.UnhealthyThreshold <= 0 {
+		a.UnhealthyThreshold = defaultUnhealthyThreshold
+	}
+	return a
+}
+
+func (a ActiveCheck) isHealthy(status int) bool {
+	if a.ExpectedStatus == 0 {
+		return status >= 200 && status < 300
+	}
+	return status == a.ExpectedStatus
+}
+
+// Checker runs active health checks for the targets of one route.
+type Checker struct {
+	route   string
+	config  ActiveCheck
+	targets []*probeState
+	client  *http.Client
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type probeState struct {
+	target    *balancer.Target
+	successes int
+	failures  int
+}
+
+func NewChecker(route string, config ActiveCheck, targets []*balancer.Target) *Checker {
+	config = config.withDefaults()
+	states := make([]*probeState, len(targets))
+	for i, t := range targets {
+		states[i] = &probeState{target: t}
+	}
+	return &Checker{
+		route:   route,
+		config:  config,
+		targets: states,
+		client:  &http.Client{Timeout: config.Timeout},
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start probes every target immediately and then once per interval
+// until Stop is called.
+func (c *Checker) Start() {
+	for _, state := range c.targets {
+		c.wg.Add(1)
+		go c.run(state)
+	}
+}
+
+func (c *Checker) Stop() {
+	close(c.stop)
+	c.wg.Wait()
+}
+
+func (c *Checker) run(state *probeState) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.check(state)
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) check(state *probeState) {
+	err := c.probe(state.target)
+	if err == nil {
+		state.failures = 0
+		state.successes++
+		if state.successes >= c.config.HealthyThreshold && state.target.SetDown(false) {
+			logger.App.Info("Upstream target is healthy", "route", c.route, "target", state.target.URL)
+		}
+		return
+	}
+
+	state.successes = 0
+	state.failures++
+	if state.failures >= c.config.UnhealthyThreshold && state.target.SetDown(true) {
+		logger.App.Warn("Upstream target is unhealthy", "route", c.route, "target", state.target.URL, "error", err)
+	}
+}
+
+func (c *Checker) probe(target *balancer.Target) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL+c.config.Path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !c.config.isHealthy(resp.StatusCode) {
+		return fmt.Errorf("unexpected health check status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"gateway-go/internal/balancer"
+	"gateway-go/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.TestSetUp()
+	os.Exit(m.Run())
+}
+
+func TestCheckerThresholds(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer backend.Close()
+
+	target := balancer.NewTarget(backend.URL, 1)
+	checker := NewChecker("/api", ActiveCheck{
+		Path:               "/ping",
+		Interval:           time.Hour,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}, []*balancer.Target{target})
+	state := checker.targets[0]
+
+	status.Store(http.StatusInternalServerError)
+	checker.check(state)
+	if !target.Available() {
+		t.Fatal("임계치 전에 unhealthy 처리됨")
+	}
+	checker.check(state)
+	if target.Status() != balancer.StatusUnhealthy {
+		t.Fatalf("unhealthy 전환 실패: %s", target.Status())
+	}
+
+	status.Store(http.StatusOK)
+	checker.check(state)
+	if target.Available() {
+		t.Fatal("임계치 전에 healthy 처리됨")
+	}
+	checker.check(state)
+	if !target.Available() {
+		t.Fatalf("healthy 전환 실패: %s", target.Status())
+	}
+}
+
+func TestCheckerStartStop(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	target := balancer.NewTarget(backend.URL, 1)
+	checker := NewChecker("/api", ActiveCheck{
+		Interval:           10 * time.Millisecond,
+		UnhealthyThreshold: 1,
+	}, []*balancer.Target{target})
+	checker.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for target.Available() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	checker.Stop()
+
+	if target.Available() {
+		t.Error("주기적 health check 실패")
+	}
+}
+
+type staticUpstreams []UpstreamStatus
+
+func (s staticUpstreams) Upstreams() []UpstreamStatus {
+	return s
+}
+
+func TestHealthHandlerUpstreams(t *testing.T) {
+	SetUpstreams(staticUpstreams{
+		{Route: "/api", Target: "http://a", Status: balancer.StatusHealthy},
+		{Route: "/api", Target: "http://b", Status: balancer.StatusEjected},
+		{Route: "/admin", Target: "http://c", Status: balancer.StatusUnhealthy},
+	})
+	defer SetUpstreams(staticUpstreams{})
+
+	rec := httptest.NewRecorder()
+	UpstreamHealthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var response HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "degraded" {
+		t.Errorf("상태 불일치: %s", response.Status)
+	}
+	if len(response.Upstreams) != 3 {
+		t.Errorf("upstream 개수 불일치: %d", len(response.Upstreams))
+	}
+
+	// 공개 listener의 /health는 upstream 정보를 노출하지 않는다
+	rec = httptest.NewRecorder()
+	HealthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	response = HealthResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "healthy" || len(response.Upstreams) != 0 {
+		t.Errorf("liveness 응답에 upstream 정보가 포함됨: %+v", response)
+	}
+}
@@ -2,22 +2,80 @@ package handler
 
 import (
 	"encoding/json"
+	"gateway-go/internal/balancer"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
+	Status    string           `json:"status"`
+	Timestamp time.Time        `json:"timestamp"`
+	Upstreams []UpstreamStatus `json:"upstreams,omitempty"`
 }
 
+// UpstreamStatus is the health state of a single route target.
+type UpstreamStatus struct {
+	Route   string `json:"route"`
+	Target  string `json:"target"`
+	Status  string `json:"status"`
+	Circuit string `json:"circuit,omitempty"`
+	Active  int64  `json:"active"`
+}
+
+// UpstreamSource reports the state of every route target.
+type UpstreamSource interface {
+	Upstreams() []UpstreamStatus
+}
+
+var upstreams atomic.Pointer[UpstreamSource]
+
+// SetUpstreams registers the source reported by UpstreamHealthHandler.
+func SetUpstreams(source UpstreamSource) {
+	upstreams.Store(&source)
+}
+
+// HealthHandler is the liveness check of the public listener. It does not
+// report upstreams, which would expose internal addresses to clients.
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, HealthResponse{
+		Status:    "healthy",
+		Timestamp: time.Now(),
+	})
+}
+
+// UpstreamHealthHandler reports the state of every route target and is
+// served on the admin listener only.
+func UpstreamHealthHandler(w http.ResponseWriter, r *http.Request) {
 	response := HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
 	}
+	if source := upstreams.Load(); source != nil {
+		response.Upstreams = (*source).Upstreams()
+		if !allRoutesServing(response.Upstreams) {
+			response.Status = "degraded"
+		}
+	}
+	writeHealth(w, response)
+}
 
+func writeHealth(w http.ResponseWriter, response HealthResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// allRoutesServing reports whether every route has at least one healthy target.
+func allRoutesServing(statuses []UpstreamStatus) bool {
+	serving := map[string]bool{}
+	for _, s := range statuses {
+		serving[s.Route] = serving[s.Route] || s.Status == balancer.StatusHealthy
+	}
+	for _, ok := range serving {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -17,4 +18,30 @@ func TestSetUp() {
 		Level:     nil,
 	})
 	initHttp(httpHandler)
+	auditSwap.swap(httpHandler)
+}
+
+// TestSetUpAudit sends audit records to w as JSON, so tests can inspect them.
+func TestSetUpAudit(w io.Writer) {
+	auditSwap.swap(slog.NewJSONHandler(w, nil))
+}
+
+// TestSetUpHTTP sends access log records to w as JSON.
+func TestSetUpHTTP(w io.Writer) {
+	httpSwap.swap(slog.NewJSONHandler(w, nil))
+}
+
+// TestSetUpTrustedProxies makes ClientIP honor X-Forwarded-For from the
+// given proxies. Calling it without proxies restores the default.
+func TestSetUpTrustedProxies(proxies ...string) {
+	options, err := (&ymlLogSetting{TrustedProxies: proxies}).accessOptions()
+	if err != nil {
+		panic(err)
+	}
+	access.Store(&options)
+}
+
+// TestSetUpApp sends application log records to w as JSON.
+func TestSetUpApp(w io.Writer) {
+	appSwap.swap(slog.NewJSONHandler(w, nil))
 }
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const redactedValue = "REDACTED"
+
+// Transaction is one request handled by the gateway, as written to the
+// access log. Route and Upstream are empty when no route matched.
+type Transaction struct {
+	Request         *http.Request
+	Status          int
+	Route           string
+	Upstream        string
+	UserId          string
+	TraceId         string
+	BytesIn         int64
+	BytesOut        int64
+	Latency         time.Duration
+	UpstreamLatency time.Duration
+	TTFB            time.Duration
+}
+
+// accessOptions are the access log settings of the http section of log.yml.
+type accessOptions struct {
+	trustedProxies []netip.Prefix
+	redactQuery    map[string]bool
+}
+
+var access atomic.Pointer[accessOptions]
+
+func init() {
+	access.Store(&accessOptions{})
+}
+
+func (yls *ymlLogSetting) accessOptions() (accessOptions, error) {
+	options := accessOptions{redactQuery: map[string]bool{}}
+	if yls == nil {
+		return options, nil
+	}
+	for _, value := range yls.TrustedProxies {
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return accessOptions{}, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		options.trustedProxies = append(options.trustedProxies, prefix)
+	}
+	for _, name := range yls.RedactQuery {
+		options.redactQuery[strings.ToLower(name)] = true
+	}
+	return options, nil
+}
+
+// parsePrefix accepts a CIDR or a single address.
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		return netip.ParsePrefix(value)
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (hl *HttpLogger) LogTransaction(t Transaction) {
+	r := t.Request
+	options := access.Load()
+	attrs := []any{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("query", options.redact(r.URL.RawQuery)),
+		slog.Int("status", t.Status),
+		slog.String("client_ip", options.clientIP(r)),
+		slog.String("route", t.Route),
+		slog.String("upstream", t.Upstream),
+		slog.String("user_id", t.UserId),
+		slog.String("trace_id", t.TraceId),
+		slog.String("proto", r.Proto),
+		slog.String("tls", tlsVersion(r)),
+		slog.Int64("bytes_in", t.BytesIn),
+		slog.Int64("bytes_out", t.BytesOut),
+		slog.Duration("latency", t.Latency),
+		slog.Duration("upstream_latency", t.UpstreamLatency),
+		slog.Duration("ttfb", t.TTFB),
+		slog.String("user_agent", r.UserAgent()),
+	}
+	// request_id는 context에서 contextHandler가 붙인다
+	hl.InfoContext(r.Context(), "HTTP Request", attrs...)
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// honored when the connection comes from a trusted proxy, and is read from
+// the right so a client cannot prepend a forged address.
+func (o *accessOptions) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !o.trusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if !o.trusted(hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+// ClientIP returns the client address the access log records for r, so
+// other parts of the gateway agree with it behind trusted proxies.
+func ClientIP(r *http.Request) string {
+	return access.Load().clientIP(r)
+}
+
+func (o *accessOptions) trusted(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range o.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact replaces the values of the configured query parameters. The
+// query is left as sent when nothing needs redacting.
+func (o *accessOptions) redact(rawQuery string) string {
+	if rawQuery == "" || len(o.redactQuery) == 0 {
+		return rawQuery
+	}
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if o.redactQuery[strings.ToLower(name)] {
+			parts[i] = key + "=" + redactedValue
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func tlsVersion(r *http.Request) string {
+	if r.TLS == nil {
+		return ""
+	}
+	return tls.VersionName(r.TLS.Version)
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	options, err := (&ymlLogSetting{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}}).accessOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"direct client", "203.0.113.5:5000", nil, "203.0.113.5"},
+		{"untrusted proxy ignored", "203.0.113.5:5000", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"forged entry skipped", "10.0.0.2:5000", []string{"1.1.1.1, 198.51.100.1", "192.168.1.1"}, "198.51.100.1"},
+		{"only proxies", "10.0.0.2:5000", []string{"10.0.0.3"}, "10.0.0.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header{}}
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := options.clientIP(r); got != tt.expected {
+				t.Errorf("client ip 불일치. 기대값: %s, 실제값: %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := (&ymlLogSetting{TrustedProxies: []string{"10.0.0.0/33"}}).accessOptions(); err == nil {
+		t.Error("잘못된 trusted proxy가 허용되었습니다")
+	}
+}
+
+func TestAuditClientIP(t *testing.T) {
+	TestSetUpTrustedProxies("10.0.0.0/8")
+	defer TestSetUpTrustedProxies()
+
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.RemoteAddr = "10.0.0.2:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	clientIP := ""
+	for _, attr := range auditAttrs(r, "/api", "") {
+		if attr := attr.(slog.Attr); attr.Key == "client_ip" {
+			clientIP = attr.Value.String()
+		}
+	}
+	if clientIP != ClientIP(r) || clientIP != "198.51.100.1" {
+		t.Errorf("audit log client_ip가 access log와 다릅니다: %q", clientIP)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	options, err := (&ymlLogSetting{RedactQuery: []string{"token", "API_KEY"}}).accessOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":                           "",
+		"page=2":                     "page=2",
+		"token=abc&page=2":           "token=REDACTED&page=2",
+		"api_key=secret&a=1&Token=x": "api_key=REDACTED&a=1&Token=REDACTED",
+		"api%5Fkey=secret&flag":      "api%5Fkey=REDACTED&flag",
+	}
+	for query, expected := range tests {
+		if got := options.redact(query); got != expected {
+			t.Errorf("redact 불일치. 기대값: %q, 실제값: %q", expected, got)
+		}
+	}
+}
@@ -18,9 +18,10 @@ const LogConfigFileName = "log.yml"
 
 const (
 	// Lumberjack default settings
-	defaultMaxSize  = 100 // MB
-	defaultMaxAge   = 1   // days
-	defaultCompress = true
+	defaultMaxSize   = 100 // MB
+	defaultMaxAge    = 1   // days
+	defaultCompress  = true
+	defaultLocalTime = true
 )
 
 type LogFormat string
@@ -44,8 +45,9 @@ func (lf *LogFormat) parse(name string) error {
 }
 
 type logConfig struct {
-	App  *ymlLogSetting `yaml:"app"`
-	Http *ymlLogSetting `yaml:"http"`
+	App   *ymlLogSetting `yaml:"app"`
+	Http  *ymlLogSetting `yaml:"http"`
+	Audit *ymlLogSetting `yaml:"audit"`
 }
 
 func (lc logConfig) appHandler() (slog.Handler, func(), error) {
@@ -59,7 +61,10 @@ func (lc logConfig) appHandler() (slog.Handler, func(), error) {
 		}
 		appWriter = appWriter1
 
-		writer = io.MultiWriter(defaultWriter, appWriter)
+		// 파일 설정이 없으면 getWriter도 stdout이므로 한 번만 쓴다
+		if lc.App.File != nil {
+			writer = io.MultiWriter(defaultWriter, appWriter)
+		}
 		handler, err := toHandler(*lc.App, writer, true)
 		if err != nil {
 			return nil, nil, err
@@ -103,6 +108,28 @@ func (lc logConfig) httpHandler() (slog.Handler, func(), error) {
 	return nil, nil, nil
 }
 
+func (lc logConfig) auditHandler() (slog.Handler, func(), error) {
+	if lc.Audit != nil {
+		auditWriter, err := lc.Audit.getWriter()
+		if err != nil {
+			return nil, nil, err
+		}
+		handler, err := toHandler(*lc.Audit, auditWriter, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		return handler, func() {
+			_ = auditWriter.Close()
+		}, nil
+	}
+
+	return nil, nil, nil
+}
+
+func (lc logConfig) httpAccessOptions() (accessOptions, error) {
+	return lc.Http.accessOptions()
+}
+
 func toHandler(yls ymlLogSetting, writer io.Writer, addSource bool) (slog.Handler, error) {
 	var logFormat LogFormat
 	err := logFormat.parse(yls.LogFormat)
@@ -128,37 +155,114 @@ func toHandler(yls ymlLogSetting, writer io.Writer, addSource bool) (slog.Handle
 	return handler, nil
 }
 
+// stdoutWriter never closes os.Stdout, since handlers built from an old
+// config are closed on reload while stdout is still in use.
+type stdoutWriter struct {
+	io.Writer
+}
+
+func (stdoutWriter) Close() error {
+	return nil
+}
+
 func defaultWrite() io.WriteCloser {
-	return os.Stdout
+	return stdoutWriter{os.Stdout}
 }
 
+// fileLoggingSetting is the file section of a logger. The file is
+// <root>/log/<fileName>.log unless path gives an absolute file path. Unset
+// rotation settings keep the lumberjack defaults above; maxBackups 0 and
+// maxAgeDays 0 keep old files forever.
 type fileLoggingSetting struct {
-	FileName string `yaml:"fileName"`
+	FileName   string `yaml:"fileName"`
+	Path       string `yaml:"path"`
+	MaxSizeMB  *int   `yaml:"maxSizeMB"`
+	MaxBackups int    `yaml:"maxBackups"`
+	MaxAgeDays *int   `yaml:"maxAgeDays"`
+	Compress   *bool  `yaml:"compress"`
+	LocalTime  *bool  `yaml:"localTime"`
+}
+
+func (f *fileLoggingSetting) validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.Path == "" && f.FileName == "" {
+		return errors.New("log file needs fileName or path")
+	}
+	if f.Path != "" && !filepath.IsAbs(f.Path) {
+		return fmt.Errorf("log file path must be absolute: %q", f.Path)
+	}
+	if f.MaxSizeMB != nil && *f.MaxSizeMB <= 0 {
+		return fmt.Errorf("maxSizeMB must be positive: %d", *f.MaxSizeMB)
+	}
+	if f.MaxBackups < 0 {
+		return fmt.Errorf("maxBackups must not be negative: %d", f.MaxBackups)
+	}
+	if f.MaxAgeDays != nil && *f.MaxAgeDays < 0 {
+		return fmt.Errorf("maxAgeDays must not be negative: %d", *f.MaxAgeDays)
+	}
+	return nil
+}
+
+func (f *fileLoggingSetting) filename() (string, error) {
+	if f.Path != "" {
+		return f.Path, nil
+	}
+	dir, err := util.GetRootDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get root directory: %w", err)
+	}
+	return filepath.Join(dir, "log", f.FileName+".log"), nil
+}
+
+func (f *fileLoggingSetting) newLumberjack() (*lumberjack.Logger, error) {
+	filename, err := f.filename()
+	if err != nil {
+		return nil, err
+	}
+	logger := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    defaultMaxSize,
+		MaxBackups: f.MaxBackups,
+		MaxAge:     defaultMaxAge,
+		LocalTime:  defaultLocalTime,
+		Compress:   defaultCompress,
+	}
+	if f.MaxSizeMB != nil {
+		logger.MaxSize = *f.MaxSizeMB
+	}
+	if f.MaxAgeDays != nil {
+		logger.MaxAge = *f.MaxAgeDays
+	}
+	if f.Compress != nil {
+		logger.Compress = *f.Compress
+	}
+	if f.LocalTime != nil {
+		logger.LocalTime = *f.LocalTime
+	}
+	return logger, nil
 }
 
 type ymlLogSetting struct {
 	Level     string              `yaml:"level"`
 	LogFormat string              `yaml:"logFormat"`
 	File      *fileLoggingSetting `yaml:"file"`
+
+	// access log 전용 설정 (http 섹션에서만 사용)
+	TrustedProxies []string `yaml:"trustedProxies"`
+	RedactQuery    []string `yaml:"redactQuery"`
 }
 
 func (yls *ymlLogSetting) getWriter() (io.WriteCloser, error) {
 	if yls == nil || yls.File == nil {
 		return defaultWrite(), nil
 	}
-	dir, err := util.GetRootDir()
+	logger, err := yls.File.newLumberjack()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get root directory: %w", err)
-	}
-	join := filepath.Join(dir, "log", yls.File.FileName+".log")
-	logger := lumberjack.Logger{
-		Filename:  join,
-		MaxSize:   defaultMaxSize,
-		MaxAge:    defaultMaxAge,
-		LocalTime: true,
-		Compress:  defaultCompress,
+		return nil, err
 	}
-	return &logger, nil
+	return newRotatingFile(logger), nil
 }
 
 func ReadConfig(data []byte) (Config, error) {
@@ -167,6 +271,17 @@ func ReadConfig(data []byte) (Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, err := config.httpAccessOptions(); err != nil {
+		return nil, err
+	}
+	for name, setting := range map[string]*ymlLogSetting{"app": config.App, "http": config.Http, "audit": config.Audit} {
+		if setting == nil {
+			continue
+		}
+		if err := setting.File.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
 
 	return config, nil
 }
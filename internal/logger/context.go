@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of ctx carrying the request id. Records
+// logged with that context, e.g. App.InfoContext(r.Context(), ...), get a
+// request_id attribute.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFrom returns the request id stored by WithRequestId, or "".
+func RequestIdFrom(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// contextHandler adds the values carried by the record's context to it.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestIdFrom(ctx); requestId != "" {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
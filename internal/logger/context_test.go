@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)}).With("component", "test")
+
+	log.InfoContext(WithRequestId(context.Background(), "req-123"), "with id")
+	log.InfoContext(context.Background(), "without id")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("로그 줄 수 = %d, 기대값 2", len(lines))
+	}
+	var first, second map[string]any
+	if err := json.Unmarshal(lines[0], &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["request_id"] != "req-123" || first["component"] != "test" {
+		t.Errorf("context의 요청 ID가 기록되지 않았습니다: %v", first)
+	}
+	if _, ok := second["request_id"]; ok {
+		t.Errorf("요청 ID가 없으면 속성도 없어야 합니다: %v", second)
+	}
+}
@@ -9,13 +9,46 @@ type HttpLogger struct {
 	*slog.Logger
 }
 
-func (hl *HttpLogger) LogTransaction(r http.Request, status int) {
-	hl.Info("HTTP Request",
+// LogAttempt records a single upstream attempt of a request that may be retried.
+// status is 0 when the attempt failed without a response.
+func (hl *HttpLogger) LogAttempt(r *http.Request, attempt int, status int, err error) {
+	attrs := []any{
 		slog.String("method", r.Method),
 		slog.String("path", r.URL.Path),
+		slog.String("upstream", r.URL.Host),
+		slog.Int("attempt", attempt),
 		slog.Int("status", status),
-		slog.String("user_agent", r.UserAgent()),
-	)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	hl.InfoContext(r.Context(), "Upstream attempt", attrs...)
+}
+
+// AuditLogger records authentication and authorization decisions.
+// Credentials are never logged, only who was accepted or why not.
+type AuditLogger struct {
+	*slog.Logger
+}
+
+func (al *AuditLogger) LogSuccess(r *http.Request, route string, userId string) {
+	al.InfoContext(r.Context(), "Auth succeeded", auditAttrs(r, route, userId)...)
+}
+
+func (al *AuditLogger) LogFailure(r *http.Request, route string, userId string, reason string) {
+	attrs := append(auditAttrs(r, route, userId), slog.String("reason", reason))
+	al.WarnContext(r.Context(), "Auth failed", attrs...)
+}
+
+func auditAttrs(r *http.Request, route string, userId string) []any {
+	// access log와 같은 요청은 같은 client_ip로 남긴다
+	return []any{
+		slog.String("route", route),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("client_ip", access.Load().clientIP(r)),
+		slog.String("user_id", userId),
+	}
 }
 
 type AppLogger struct {
@@ -23,21 +56,36 @@ type AppLogger struct {
 }
 
 var (
-	App  AppLogger
-	HTTP HttpLogger
+	appSwap   = newSwapHandler(defaultHandler())
+	httpSwap  = newSwapHandler(defaultHandler())
+	auditSwap = newSwapHandler(defaultHandler())
+
+	App   = AppLogger{slog.New(contextHandler{appSwap})}
+	HTTP  = HttpLogger{slog.New(contextHandler{httpSwap})}
+	Audit = AuditLogger{slog.New(contextHandler{auditSwap})}
 )
 
+func defaultHandler() slog.Handler {
+	return slog.NewTextHandler(defaultWrite(), nil)
+}
+
 type Config interface {
 	appHandler() (slog.Handler, func(), error)
 	httpHandler() (slog.Handler, func(), error)
+	auditHandler() (slog.Handler, func(), error)
+	httpAccessOptions() (accessOptions, error)
 }
 
 // SetUp initializes all loggers with the given config.
 // Returns a cleanup function that safely closes all open log files.
 // The cleanup function is safe to call even if initialization failed.
+// Handlers are only installed when every handler was built successfully,
+// so a failed call leaves the current loggers untouched. SetUp may be called
+// again to reload the config; the caller closes the previous cleanup function.
 func SetUp(config Config) (func(), error) {
 	var appCloser func()
 	var httpCloser func()
+	var auditCloser func()
 
 	handler, closer, err := config.appHandler()
 	if err != nil {
@@ -45,22 +93,55 @@ func SetUp(config Config) (func(), error) {
 		return func() {}, err
 	}
 	appCloser = closer
-	initApp(handler)
 
 	httpHandler, closer, err := config.httpHandler()
 	if err != nil {
+		if appCloser != nil {
+			appCloser()
+		}
 		// Return safe cleanup even on error
-		return func() {
-			if appCloser != nil {
-				appCloser()
-			}
-		}, err
+		return func() {}, err
 	}
 	httpCloser = closer
 
-	if httpHandler != nil {
-		initHttp(httpHandler)
+	auditHandler, closer, err := config.auditHandler()
+	if err != nil {
+		if appCloser != nil {
+			appCloser()
+		}
+		if httpCloser != nil {
+			httpCloser()
+		}
+		// Return safe cleanup even on error
+		return func() {}, err
 	}
+	auditCloser = closer
+
+	options, err := config.httpAccessOptions()
+	if err != nil {
+		if appCloser != nil {
+			appCloser()
+		}
+		if httpCloser != nil {
+			httpCloser()
+		}
+		if auditCloser != nil {
+			auditCloser()
+		}
+		// Return safe cleanup even on error
+		return func() {}, err
+	}
+
+	if httpHandler == nil {
+		httpHandler = defaultHandler()
+	}
+	if auditHandler == nil {
+		auditHandler = defaultHandler()
+	}
+	initApp(handler)
+	initHttp(httpHandler)
+	auditSwap.swap(auditHandler)
+	access.Store(&options)
 
 	// Always return valid cleanup function
 	return func() {
@@ -70,19 +151,18 @@ func SetUp(config Config) (func(), error) {
 		if httpCloser != nil {
 			httpCloser()
 		}
+		if auditCloser != nil {
+			auditCloser()
+		}
 	}, nil
 }
 
 // initHttp initializes HTTP logger with the given handler.
 // handler must not be nil (checked by caller).
 func initHttp(handler slog.Handler) {
-	HTTP = HttpLogger{
-		slog.New(handler),
-	}
+	httpSwap.swap(handler)
 }
 
 func initApp(handler slog.Handler) {
-	App = AppLogger{
-		slog.New(handler),
-	}
+	appSwap.swap(handler)
 }
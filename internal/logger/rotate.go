@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// openFiles holds the log files of the handlers built from log.yml, so
+// Rotate can reach them. Files leave the set when their handler is closed
+// after a reload.
+var openFiles = struct {
+	sync.Mutex
+	files map[*rotatingFile]struct{}
+}{files: map[*rotatingFile]struct{}{}}
+
+type rotatingFile struct {
+	*lumberjack.Logger
+}
+
+func newRotatingFile(logger *lumberjack.Logger) *rotatingFile {
+	file := &rotatingFile{logger}
+	openFiles.Lock()
+	openFiles.files[file] = struct{}{}
+	openFiles.Unlock()
+	return file
+}
+
+func (f *rotatingFile) Close() error {
+	openFiles.Lock()
+	delete(openFiles.files, f)
+	openFiles.Unlock()
+	return f.Logger.Close()
+}
+
+// Rotate closes every open log file, renames it with a timestamp and starts
+// a new one, as lumberjack does when a file reaches maxSizeMB. It lets log
+// shippers trigger rotation, e.g. on SIGUSR1.
+func Rotate() error {
+	openFiles.Lock()
+	defer openFiles.Unlock()
+	var errs []error
+	for file := range openFiles.files {
+		if err := file.Rotate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSettings(t *testing.T) {
+	config, err := ReadConfig([]byte(`http:
+  file:
+    path: /var/log/gateway/access.log
+    maxSizeMB: 10
+    maxBackups: 5
+    maxAgeDays: 0
+    compress: false
+    localTime: false
+audit:
+  file:
+    fileName: audit
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := config.(logConfig)
+
+	access, err := lc.Http.File.newLumberjack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access.Filename != "/var/log/gateway/access.log" || access.MaxSize != 10 || access.MaxBackups != 5 ||
+		access.MaxAge != 0 || access.Compress || access.LocalTime {
+		t.Errorf("http 파일 설정이 잘못되었습니다: %+v", access)
+	}
+
+	audit, err := lc.Audit.File.newLumberjack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(audit.Filename) != "audit.log" || audit.MaxSize != defaultMaxSize || audit.MaxAge != defaultMaxAge ||
+		audit.Compress != defaultCompress || audit.LocalTime != defaultLocalTime {
+		t.Errorf("기본 파일 설정이 잘못되었습니다: %+v", audit)
+	}
+
+	for _, invalid := range []string{
+		"app:\n  file:\n    path: log/app.log\n",
+		"app:\n  file:\n    maxSizeMB: 10\n",
+		"http:\n  file:\n    fileName: access\n    maxSizeMB: 0\n",
+		"audit:\n  file:\n    fileName: audit\n    maxBackups: -1\n",
+	} {
+		if _, err := ReadConfig([]byte(invalid)); err == nil {
+			t.Errorf("잘못된 설정이 통과했습니다: %q", invalid)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	config, err := ReadConfig([]byte("http:\n  logFormat: json\n  file:\n    path: " + path + "\n    compress: false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeLog, err := SetUp(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer TestSetUp()
+
+	HTTP.Info("before rotation")
+	if err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	HTTP.Info("after rotation")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("rotate 후 파일 수 = %d, 기대값 2", len(entries))
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(current), "after rotation") || strings.Contains(string(current), "before rotation") {
+		t.Errorf("새 로그 파일 내용이 잘못되었습니다: %s", current)
+	}
+
+	// 닫힌 파일은 더 이상 rotate 대상이 아니다
+	closeLog()
+	if err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("닫힌 로그 파일이 rotate되었습니다: %d", len(entries))
+	}
+}
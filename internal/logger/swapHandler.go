@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// swapHandler forwards records to a handler that can be replaced at runtime,
+// so App and HTTP keep working while log.yml is reloaded.
+type swapHandler struct {
+	current *atomic.Pointer[slog.Handler]
+	derive  func(slog.Handler) slog.Handler
+}
+
+func newSwapHandler(handler slog.Handler) *swapHandler {
+	current := &atomic.Pointer[slog.Handler]{}
+	current.Store(&handler)
+	return &swapHandler{current: current}
+}
+
+func (s *swapHandler) swap(handler slog.Handler) {
+	s.current.Store(&handler)
+}
+
+func (s *swapHandler) handler() slog.Handler {
+	h := *s.current.Load()
+	if s.derive != nil {
+		return s.derive(h)
+	}
+	return h
+}
+
+func (s *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.handler().Enabled(ctx, level)
+}
+
+func (s *swapHandler) Handle(ctx context.Context, record slog.Record) error {
+	return s.handler().Handle(ctx, record)
+}
+
+func (s *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return s.with(func(h slog.Handler) slog.Handler {
+		return h.WithAttrs(attrs)
+	})
+}
+
+func (s *swapHandler) WithGroup(name string) slog.Handler {
+	return s.with(func(h slog.Handler) slog.Handler {
+		return h.WithGroup(name)
+	})
+}
+
+func (s *swapHandler) with(next func(slog.Handler) slog.Handler) slog.Handler {
+	derive := next
+	if prev := s.derive; prev != nil {
+		derive = func(h slog.Handler) slog.Handler {
+			return next(prev(h))
+		}
+	}
+	return &swapHandler{current: s.current, derive: derive}
+}
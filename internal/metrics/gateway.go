@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Default is the registry served on /metrics.
+var Default = NewRegistry()
+
+// Gateway metrics. Route is the matched host and prefix, or "unmatched"
+// for requests that matched no route.
+var (
+	Requests = Default.NewCounter("gateway_requests_total",
+		"Requests handled by the gateway.", "route", "method", "status_class")
+	RequestDuration = Default.NewHistogram("gateway_request_duration_seconds",
+		"Time from receiving a request to finishing its response.", DefBuckets, "route", "method", "status_class")
+	InFlight = Default.NewGauge("gateway_requests_in_flight",
+		"Requests currently being handled.", "route")
+	UpstreamErrors = Default.NewCounter("gateway_upstream_errors_total",
+		"Upstream requests that failed without a response.", "route", "kind")
+	AuthFailures = Default.NewCounter("gateway_auth_failures_total",
+		"Requests rejected by authentication or authorization.", "provider", "reason")
+	RateLimited = Default.NewCounter("gateway_rate_limited_total",
+		"Requests rejected by a route rate limit.", "route")
+	CircuitRejected = Default.NewCounter("gateway_circuit_breaker_rejections_total",
+		"Requests rejected because the circuit breakers of the route's targets refused them.", "route")
+	CircuitTransitions = Default.NewCounter("gateway_circuit_breaker_transitions_total",
+		"Circuit breaker state changes.", "breaker", "state")
+)
+
+const (
+	UnmatchedRoute = "unmatched"
+	OtherMethod    = "OTHER"
+)
+
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// Method returns the method label of a request. net/http accepts any
+// token as a method, so anything outside the standard set is reported as
+// OTHER to keep the number of series bounded.
+func Method(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return OtherMethod
+}
+
+// StatusClass returns the class label of an HTTP status, such as 2xx.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,319 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metrics and writes them in the Prometheus text exposition
+// format. Metrics are created once at startup and live for the process.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the registry as a Prometheus scrape target.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// family is the part shared by every metric type: name, help, label names
+// and one series per distinct label values.
+type family[S any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	newS   func() *S
+
+	mu     sync.RWMutex
+	series map[string]*labeled[S]
+}
+
+type labeled[S any] struct {
+	values []string
+	s      *S
+}
+
+func newFamily[S any](name, help, kind string, labels []string, newS func() *S) *family[S] {
+	return &family[S]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		newS:   newS,
+		series: map[string]*labeled[S]{},
+	}
+}
+
+func (f *family[S]) with(values []string) *S {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s needs %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.RLock()
+	entry, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return entry.s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if entry, ok := f.series[key]; ok {
+		return entry.s
+	}
+	entry = &labeled[S]{values: append([]string(nil), values...), s: f.newS()}
+	f.series[key] = entry
+	return entry.s
+}
+
+// each calls fn for every series in a stable order.
+func (f *family[S]) each(fn func(values []string, s *S)) {
+	f.mu.RLock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]*labeled[S], len(keys))
+	for i, key := range keys {
+		entries[i] = f.series[key]
+	}
+	f.mu.RUnlock()
+
+	for _, entry := range entries {
+		fn(entry.values, entry.s)
+	}
+}
+
+func (f *family[S]) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+// Counter only goes up.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(v float64) {
+	addFloat(&c.bits, v)
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+type CounterVec struct {
+	f *family[Counter]
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{f: newFamily(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(v)
+	return v
+}
+
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.with(values)
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	v.f.writeHeader(w)
+	v.f.each(func(values []string, c *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", v.f.name, formatLabels(v.f.labels, values), formatFloat(c.Value()))
+	})
+}
+
+// Gauge goes up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+type GaugeVec struct {
+	f *family[Gauge]
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{f: newFamily(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.register(v)
+	return v
+}
+
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.with(values)
+}
+
+func (v *GaugeVec) write(w io.Writer) {
+	v.f.writeHeader(w)
+	v.f.each(func(values []string, g *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", v.f.name, formatLabels(v.f.labels, values), formatFloat(g.Value()))
+	})
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type HistogramVec struct {
+	f *family[Histogram]
+}
+
+// DefBuckets suits request latencies in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	newHistogram := func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	}
+	v := &HistogramVec{f: newFamily(name, help, "histogram", labels, newHistogram)}
+	r.register(v)
+	return v
+}
+
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.with(values)
+}
+
+func (v *HistogramVec) write(w io.Writer) {
+	v.f.writeHeader(w)
+	bucketLabels := append(append([]string(nil), v.f.labels...), "le")
+	v.f.each(func(values []string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		for i, upper := range h.buckets {
+			labels := formatLabels(bucketLabels, append(append([]string(nil), values...), formatFloat(upper)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.f.name, labels, counts[i])
+		}
+		labels := formatLabels(bucketLabels, append(append([]string(nil), values...), "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.f.name, labels, count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.f.name, formatLabels(v.f.labels, values), formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.f.name, formatLabels(v.f.labels, values), count)
+	})
+}
+
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
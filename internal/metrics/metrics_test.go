@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("test_requests_total", "Requests.", "route", "method")
+	inFlight := registry.NewGauge("test_in_flight", "In flight.")
+	latency := registry.NewHistogram("test_latency_seconds", "Latency.", []float64{0.5, 0.1}, "route")
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requests.With("/api", "GET").Inc()
+		}()
+	}
+	wg.Wait()
+	requests.With(`/a"b`, "POST").Add(2)
+	inFlight.With().Inc()
+	inFlight.With().Inc()
+	inFlight.With().Dec()
+	latency.With("/api").Observe(0.05)
+	latency.With("/api").Observe(0.3)
+	latency.With("/api").Observe(2)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	expected := []string{
+		"# HELP test_requests_total Requests.\n# TYPE test_requests_total counter\n",
+		`test_requests_total{route="/api",method="GET"} 100`,
+		`test_requests_total{route="/a\"b",method="POST"} 2`,
+		"# TYPE test_in_flight gauge\ntest_in_flight 1\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{route="/api",le="0.1"} 1`,
+		`test_latency_seconds_bucket{route="/api",le="0.5"} 2`,
+		`test_latency_seconds_bucket{route="/api",le="+Inf"} 3`,
+		`test_latency_seconds_sum{route="/api"} 2.35`,
+		`test_latency_seconds_count{route="/api"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics 출력에 %q가 없습니다:\n%s", line, body)
+		}
+	}
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type 불일치: %s", recorder.Header().Get("Content-Type"))
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]string{200: "2xx", 404: "4xx", 503: "5xx", 0: "unknown"}
+	for status, expected := range tests {
+		if got := StatusClass(status); got != expected {
+			t.Errorf("%d: 기대값: %s, 실제값: %s", status, expected, got)
+		}
+	}
+}
+
+func TestMethod(t *testing.T) {
+	tests := map[string]string{"GET": "GET", "DELETE": "DELETE", "get": "OTHER", "JUNK0": "OTHER", "": "OTHER"}
+	for method, expected := range tests {
+		if got := Method(method); got != expected {
+			t.Errorf("%q: 기대값: %s, 실제값: %s", method, expected, got)
+		}
+	}
+}
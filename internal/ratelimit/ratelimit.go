@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"gateway-go/internal/auth"
+	"gateway-go/internal/logger"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Algorithm string
+
+const (
+	TokenBucket   Algorithm = "token-bucket"
+	SlidingWindow Algorithm = "sliding-window"
+)
+
+const (
+	KeyIP           = "ip"
+	KeyUser         = "user"
+	keyHeaderPrefix = "header:"
+
+	// 오래 사용되지 않은 key 정리 주기
+	sweepInterval = time.Minute
+)
+
+// Config is the per-route rate_limit block in config.yml.
+// Burst is the number of requests admitted at once and defaults to
+// RequestsPerSecond; RequestsPerSecond is the sustained rate.
+type Config struct {
+	Algorithm         string  `yaml:"algorithm"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+	Key               string  `yaml:"key"`
+}
+
+// Result describes the decision for a single request.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	algorithm Algorithm
+	rate      float64
+	burst     int
+	keyFunc   func(*http.Request) string
+	byUser    bool
+
+	mu        sync.Mutex
+	states    map[string]*state
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// state holds either token bucket or sliding window counters for one key.
+type state struct {
+	tokens   float64
+	last     time.Time
+	window   time.Time
+	current  int
+	previous int
+}
+
+func New(config Config) (*Limiter, error) {
+	if config.RequestsPerSecond <= 0 {
+		return nil, errors.New("rate limit requests_per_second must be positive")
+	}
+	if config.Burst < 0 {
+		return nil, errors.New("rate limit burst must not be negative")
+	}
+	burst := config.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(config.RequestsPerSecond))
+	}
+
+	algorithm := Algorithm(strings.ToLower(config.Algorithm))
+	switch algorithm {
+	case "":
+		algorithm = TokenBucket
+	case TokenBucket, SlidingWindow:
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm: %q", config.Algorithm)
+	}
+
+	keyFunc, err := parseKey(config.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limiter{
+		algorithm: algorithm,
+		rate:      config.RequestsPerSecond,
+		burst:     burst,
+		keyFunc:   keyFunc,
+		byUser:    strings.EqualFold(config.Key, KeyUser),
+		states:    map[string]*state{},
+		now:       time.Now,
+	}, nil
+}
+
+func parseKey(key string) (func(*http.Request) string, error) {
+	lower := strings.ToLower(key)
+	switch {
+	case lower == "" || lower == KeyIP:
+		return clientIP, nil
+	case lower == KeyUser:
+		// 헤더 이름은 설정에 따라 바뀌므로 인증이 남긴 identity를 쓴다
+		return func(r *http.Request) string {
+			if identity, ok := auth.IdentityFrom(r); ok && identity.UserId != "" {
+				return "user:" + identity.UserId
+			}
+			return clientIP(r)
+		}, nil
+	case strings.HasPrefix(lower, keyHeaderPrefix):
+		name := http.CanonicalHeaderKey(strings.TrimSpace(key[len(keyHeaderPrefix):]))
+		if name == "" {
+			return nil, errors.New("rate limit header key needs a header name")
+		}
+		return func(r *http.Request) string {
+			return "header:" + r.Header.Get(name)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key: %q", key)
+	}
+}
+
+// clientIP keys on the address the access log records, which honors
+// X-Forwarded-For from trusted proxies.
+func clientIP(r *http.Request) string {
+	return "ip:" + logger.ClientIP(r)
+}
+
+// KeyedByUser reports whether the limiter keys on the authenticated user.
+// Such a limiter must run after auth; the others run before it so failed
+// credentials are throttled too.
+func (l *Limiter) KeyedByUser() bool {
+	return l.byUser
+}
+
+// Allow consumes one request for the client identified by r.
+func (l *Limiter) Allow(r *http.Request) Result {
+	key := l.keyFunc(r)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	s, ok := l.states[key]
+	if !ok {
+		s = &state{tokens: float64(l.burst), last: now, window: now}
+		l.states[key] = s
+	}
+
+	if l.algorithm == SlidingWindow {
+		return l.allowSlidingWindow(s, now)
+	}
+	return l.allowTokenBucket(s, now)
+}
+
+func (l *Limiter) allowTokenBucket(s *state, now time.Time) Result {
+	elapsed := now.Sub(s.last).Seconds()
+	s.tokens = math.Min(float64(l.burst), s.tokens+elapsed*l.rate)
+	s.last = now
+
+	result := Result{Limit: l.burst}
+	if s.tokens >= 1 {
+		s.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - s.tokens) / l.rate)
+	}
+	result.Remaining = int(s.tokens)
+	result.Reset = seconds((float64(l.burst) - s.tokens) / l.rate)
+	return result
+}
+
+// allowSlidingWindow approximates a sliding log with two fixed windows,
+// weighting the previous window by how much of it still overlaps.
+// The window is sized so that Burst requests per window matches the rate.
+func (l *Limiter) allowSlidingWindow(s *state, now time.Time) Result {
+	window := seconds(float64(l.burst) / l.rate)
+	s.last = now
+	elapsed := now.Sub(s.window)
+	if elapsed >= window {
+		if elapsed < 2*window {
+			s.previous = s.current
+		} else {
+			s.previous = 0
+		}
+		s.current = 0
+		s.window = s.window.Add(elapsed.Truncate(window))
+		elapsed = now.Sub(s.window)
+	}
+
+	overlap := 1 - float64(elapsed)/float64(window)
+	count := float64(s.previous)*overlap + float64(s.current)
+
+	result := Result{Limit: l.burst, Reset: window - elapsed}
+	if count+1 <= float64(l.burst) {
+		s.current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = result.Reset
+	}
+	result.Remaining = max(0, l.burst-int(math.Ceil(count)))
+	return result
+}
+
+// sweep drops keys that have been idle long enough to be back at full capacity.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	idle := 2 * seconds(float64(l.burst)/l.rate)
+	for key, s := range l.states {
+		if now.Sub(s.last) > idle {
+			delete(l.states, key)
+		}
+	}
+}
+
+// SetHeaders writes the RateLimit-* response headers and, when the request
+// was rejected, Retry-After.
+func (r Result) SetHeaders(h http.Header) {
+	h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(r.Reset)))
+	if !r.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(r.RetryAfter))))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
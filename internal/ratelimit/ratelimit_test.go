@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"gateway-go/internal/auth"
+	"gateway-go/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(t *testing.T, config Config) (*Limiter, *fakeClock) {
+	limiter, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func request(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestTokenBucket(t *testing.T) {
+	limiter, clock := newTestLimiter(t, Config{RequestsPerSecond: 2, Burst: 3})
+	req := request("10.0.0.1:1234")
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow(req).Allowed {
+			t.Fatalf("burst 내 요청 거부: %d", i)
+		}
+	}
+	result := limiter.Allow(req)
+	if result.Allowed {
+		t.Fatal("burst 초과 요청 허용")
+	}
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Retry-After 불일치: %v", result.RetryAfter)
+	}
+
+	if !limiter.Allow(request("10.0.0.2:1234")).Allowed {
+		t.Error("다른 클라이언트 요청 거부")
+	}
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	if !limiter.Allow(req).Allowed {
+		t.Error("토큰 충전 후 요청 거부")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limiter, clock := newTestLimiter(t, Config{Algorithm: "sliding-window", RequestsPerSecond: 2, Burst: 2})
+	req := request("10.0.0.1:1234")
+
+	if !limiter.Allow(req).Allowed || !limiter.Allow(req).Allowed {
+		t.Fatal("window 내 요청 거부")
+	}
+	if limiter.Allow(req).Allowed {
+		t.Fatal("window 초과 요청 허용")
+	}
+
+	// 이전 window의 절반이 겹치므로 한 건만 허용
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	if !limiter.Allow(req).Allowed {
+		t.Fatal("window 이동 후 요청 거부")
+	}
+	if limiter.Allow(req).Allowed {
+		t.Fatal("이전 window 가중치 미반영")
+	}
+
+	clock.now = clock.now.Add(5 * time.Second)
+	if result := limiter.Allow(req); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("window 초기화 실패: %+v", result)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	userLimiter, _ := newTestLimiter(t, Config{RequestsPerSecond: 1, Key: "user"})
+	if !userLimiter.KeyedByUser() {
+		t.Error("user key limiter는 인증 후에 적용되어야 합니다")
+	}
+	first := request("10.0.0.1:1234")
+	first = first.WithContext(auth.WithIdentity(first.Context(), auth.Identity{UserId: "alice"}))
+	second := request("10.0.0.1:1234")
+	second = second.WithContext(auth.WithIdentity(second.Context(), auth.Identity{UserId: "bob"}))
+	if !userLimiter.Allow(first).Allowed || !userLimiter.Allow(second).Allowed {
+		t.Error("사용자별 key 분리 실패")
+	}
+	// 클라이언트가 보낸 X-User-Id 헤더는 사용자 key로 쓰지 않는다
+	forged := request("10.0.0.3:1234")
+	forged.Header.Set("X-User-Id", "carol")
+	unauthenticated := request("10.0.0.3:1234")
+	unauthenticated.Header.Set("X-User-Id", "dave")
+	userLimiter.Allow(forged)
+	if userLimiter.Allow(unauthenticated).Allowed {
+		t.Error("인증되지 않은 요청은 IP로 제한되어야 합니다")
+	}
+
+	headerLimiter, _ := newTestLimiter(t, Config{RequestsPerSecond: 1, Key: "header:x-api-key"})
+	if headerLimiter.KeyedByUser() {
+		t.Error("header key limiter는 인증 전에 적용되어야 합니다")
+	}
+	a := request("10.0.0.1:1234")
+	a.Header.Set("X-Api-Key", "a")
+	b := request("10.0.0.2:1234")
+	b.Header.Set("X-Api-Key", "a")
+	headerLimiter.Allow(a)
+	if headerLimiter.Allow(b).Allowed {
+		t.Error("header key 공유 실패")
+	}
+}
+
+func TestTrustedProxyClientIP(t *testing.T) {
+	logger.TestSetUpTrustedProxies("10.0.0.0/8")
+	defer logger.TestSetUpTrustedProxies()
+
+	limiter, _ := newTestLimiter(t, Config{RequestsPerSecond: 1})
+	// 같은 load balancer를 거친 클라이언트도 각자의 bucket을 쓴다
+	first := request("10.0.0.1:1234")
+	first.Header.Set("X-Forwarded-For", "203.0.113.1")
+	second := request("10.0.0.1:1234")
+	second.Header.Set("X-Forwarded-For", "203.0.113.2")
+	if !limiter.Allow(first).Allowed || !limiter.Allow(second).Allowed {
+		t.Error("proxy 뒤의 클라이언트 key 분리 실패")
+	}
+	if limiter.Allow(first).Allowed {
+		t.Error("같은 클라이언트 제한 실패")
+	}
+
+	// 신뢰하지 않는 연결이 보낸 X-Forwarded-For는 무시한다
+	forged := request("203.0.113.9:1234")
+	forged.Header.Set("X-Forwarded-For", "198.51.100.1")
+	other := request("203.0.113.9:1234")
+	other.Header.Set("X-Forwarded-For", "198.51.100.2")
+	limiter.Allow(forged)
+	if limiter.Allow(other).Allowed {
+		t.Error("위조한 X-Forwarded-For로 제한 우회")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	configs := []Config{
+		{RequestsPerSecond: 0},
+		{RequestsPerSecond: 1, Algorithm: "leaky"},
+		{RequestsPerSecond: 1, Key: "cookie"},
+		{RequestsPerSecond: 1, Key: "header:"},
+	}
+	for _, config := range configs {
+		if _, err := New(config); err == nil {
+			t.Errorf("잘못된 설정 검증 실패: %+v", config)
+		}
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	header := http.Header{}
+	Result{Limit: 10, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 200 * time.Millisecond}.SetHeaders(header)
+
+	if header.Get("RateLimit-Limit") != "10" || header.Get("RateLimit-Remaining") != "0" ||
+		header.Get("RateLimit-Reset") != "2" || header.Get("Retry-After") != "1" {
+		t.Errorf("헤더 불일치: %v", header)
+	}
+}
@@ -0,0 +1,169 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const wildcardPrefix = "*."
+
+// 호스트 매칭 우선순위: 정확한 호스트 > 와일드카드 호스트 > 호스트 조건 없음
+const (
+	hostAny = iota
+	hostWildcard
+	hostExact
+)
+
+// precedes reports whether a must be evaluated before b.
+// Routes are ordered by host specificity, then by longest prefix,
+// then by the number of header conditions, and finally routes restricted
+// to specific methods come before routes accepting every method.
+func precedes(a, b Route) bool {
+	if ra, rb := hostRank(a.Host), hostRank(b.Host); ra != rb {
+		return ra > rb
+	}
+	if len(a.Host) != len(b.Host) {
+		return len(a.Host) > len(b.Host)
+	}
+	if len(a.Prefix) != len(b.Prefix) {
+		return len(a.Prefix) > len(b.Prefix)
+	}
+	if len(a.Headers) != len(b.Headers) {
+		return len(a.Headers) > len(b.Headers)
+	}
+	return len(a.Methods) > 0 && len(b.Methods) == 0
+}
+
+func hostRank(pattern string) int {
+	switch {
+	case pattern == "":
+		return hostAny
+	case strings.HasPrefix(pattern, wildcardPrefix):
+		return hostWildcard
+	default:
+		return hostExact
+	}
+}
+
+// matchHost matches host against pattern. A pattern of "*.example.com"
+// matches any subdomain of example.com but not example.com itself.
+func matchHost(pattern, host string) bool {
+	switch hostRank(pattern) {
+	case hostAny:
+		return true
+	case hostWildcard:
+		suffix := pattern[len(wildcardPrefix)-1:]
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	default:
+		return pattern == host
+	}
+}
+
+func (route Route) matchMethod(method string) bool {
+	if len(route.Methods) == 0 {
+		return true
+	}
+	for _, m := range route.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHeaders requires every configured header to be present.
+// An empty value or "*" only checks presence, anything else must match exactly.
+func (route Route) matchHeaders(header http.Header) bool {
+	for name, expected := range route.Headers {
+		values, ok := header[name]
+		if !ok || len(values) == 0 {
+			return false
+		}
+		if expected == "" || expected == "*" {
+			continue
+		}
+		matched := false
+		for _, v := range values {
+			if v == expected {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func isValidHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	rest := strings.TrimPrefix(host, wildcardPrefix)
+	return rest != "" && !strings.Contains(rest, "*") && !strings.Contains(rest, "/")
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func normalizeMethods(methods []string) []string {
+	if len(methods) == 0 {
+		return nil
+	}
+	result := make([]string, len(methods))
+	for i, m := range methods {
+		result[i] = strings.ToUpper(m)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func normalizeHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		result[http.CanonicalHeaderKey(name)] = value
+	}
+	return result
+}
+
+// matchKey identifies routes that would match exactly the same requests.
+func matchKey(route Route) string {
+	var b strings.Builder
+	b.WriteString(normalizeHost(route.Host))
+	b.WriteString("|")
+	b.WriteString(normalize(route.Prefix))
+	b.WriteString("|")
+	b.WriteString(strings.Join(normalizeMethods(route.Methods), ","))
+
+	headers := normalizeHeaders(route.Headers)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("|")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(headers[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxAttempts  = 3
+	defaultBackoff      = 100 * time.Millisecond
+	defaultMaxBackoff   = time.Second
+	defaultMaxBodyBytes = 1 << 20
+)
+
+var defaultRetryStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// RetryPolicy is the per-route retry block in config.yml. MaxAttempts
+// includes the first attempt. Request bodies larger than MaxBodyBytes are
+// streamed instead of buffered and are never retried.
+type RetryPolicy struct {
+	MaxAttempts        int           `yaml:"max_attempts"`
+	RetryOnStatus      []int         `yaml:"retry_on_status"`
+	RetryOnErrors      *bool         `yaml:"retry_on_errors"`
+	Backoff            time.Duration `yaml:"backoff"`
+	MaxBackoff         time.Duration `yaml:"max_backoff"`
+	RetryNonIdempotent bool          `yaml:"retry_non_idempotent"`
+	MaxBodyBytes       int64         `yaml:"max_body_bytes"`
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.MaxBodyBytes < 0 {
+		return errors.New("retry max_attempts and max_body_bytes must not be negative")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("retry backoff must not be negative")
+	}
+	for _, status := range p.RetryOnStatus {
+		if status < 100 || status > 599 {
+			return errors.New("retry_on_status must be HTTP status codes")
+		}
+	}
+	return nil
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if len(p.RetryOnStatus) == 0 {
+		p.RetryOnStatus = defaultRetryStatuses
+	}
+	if p.RetryOnErrors == nil {
+		retryOnErrors := true
+		p.RetryOnErrors = &retryOnErrors
+	}
+	if p.Backoff == 0 {
+		p.Backoff = defaultBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.MaxBodyBytes == 0 {
+		p.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	return p
+}
+
+// AllowsMethod reports whether requests with method may be retried.
+func (p RetryPolicy) AllowsMethod(method string) bool {
+	if p.RetryNonIdempotent {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Retryable reports whether an attempt that ended with resp or err
+// should be retried.
+func (p RetryPolicy) Retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return *p.RetryOnErrors
+	}
+	for _, status := range p.RetryOnStatus {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// BackoffFor returns the wait before the given retry (1 for the first retry):
+// exponential backoff capped at MaxBackoff, jittered into its upper half.
+func (p RetryPolicy) BackoffFor(retry int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, p.MaxBackoff)
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
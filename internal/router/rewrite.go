@@ -0,0 +1,105 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule rewrites the request path with a regular expression.
+// Replace may reference capture groups ($1, ${name}) and may contain a
+// query string, e.g. match "^/v1/users/([^/]+)$" replace "/users?id=$1".
+// Captures used in the query string are query-escaped, so a path segment
+// such as "bob&role=admin" stays a single value.
+type RewriteRule struct {
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+
+	pattern *regexp.Regexp
+}
+
+func compileRewrites(rules []RewriteRule) ([]RewriteRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	compiled := make([]RewriteRule, len(rules))
+	for i, rule := range rules {
+		pattern, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite pattern %q: %w", rule.Match, err)
+		}
+		compiled[i] = RewriteRule{
+			Match:   rule.Match,
+			Replace: rule.Replace,
+			pattern: pattern,
+		}
+	}
+	return compiled, nil
+}
+
+// upstreamPath maps the normalized request path to the path sent upstream.
+// The first rewrite rule matching the request path decides the whole
+// upstream path. Without a matching rule the route prefix is stripped
+// (unless strip_prefix is false) and add_prefix is prepended.
+func (route Route) upstreamPath(path string) string {
+	for _, rule := range route.Rewrite {
+		if rule.pattern.MatchString(path) {
+			return rule.apply(path)
+		}
+	}
+
+	after := path
+	if route.stripPrefix() && route.Prefix != root {
+		after = path[len(route.Prefix):]
+	}
+	if route.AddPrefix != "" && route.AddPrefix != root {
+		after = route.AddPrefix + after
+	}
+	return after
+}
+
+func (rule RewriteRule) apply(path string) string {
+	pathTemplate, queryTemplate, hasQuery := strings.Cut(rule.Replace, "?")
+	if !hasQuery {
+		return rule.pattern.ReplaceAllString(path, rule.Replace)
+	}
+
+	var result []byte
+	last := 0
+	for _, match := range rule.pattern.FindAllStringSubmatchIndex(path, -1) {
+		result = append(result, path[last:match[0]]...)
+		result = rule.pattern.ExpandString(result, pathTemplate, path, match)
+		result = append(result, '?')
+		src, escaped := queryEscapeGroups(path, match)
+		result = rule.pattern.ExpandString(result, queryTemplate, src, escaped)
+		last = match[1]
+	}
+	return string(append(result, path[last:]...))
+}
+
+// queryEscapeGroups returns the capture groups of match as query-escaped
+// values, with indices into the returned string for Regexp.ExpandString.
+// The path is escaped, so each value is path-unescaped first.
+func queryEscapeGroups(path string, match []int) (string, []int) {
+	var b strings.Builder
+	escaped := make([]int, len(match))
+	for i := 0; i < len(match); i += 2 {
+		if match[i] < 0 {
+			escaped[i], escaped[i+1] = -1, -1
+			continue
+		}
+		value := path[match[i]:match[i+1]]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		escaped[i] = b.Len()
+		b.WriteString(url.QueryEscape(value))
+		escaped[i+1] = b.Len()
+	}
+	return b.String(), escaped
+}
+
+func (route Route) stripPrefix() bool {
+	return route.StripPrefix == nil || *route.StripPrefix
+}
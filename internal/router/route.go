@@ -1,10 +1,20 @@
 package router
 
 import (
+	"errors"
 	"fmt"
 	"gateway-go/internal/auth"
+	"gateway-go/internal/balancer"
+	"gateway-go/internal/breaker"
+	handler "gateway-go/internal/health"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/ratelimit"
+	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,19 +24,140 @@ const RouterConfigName = "config.yml"
 const root = "/"
 const pathSeparator = "/"
 
+var (
+	ErrNotFound          = errors.New("route not found")
+	ErrNoAvailableTarget = errors.New("no available upstream target")
+)
+
 type Router struct {
-	routes []Route // 소문자 (외부 노출 불필요)
+	routes   []Route // 소문자 (외부 노출 불필요)
+	checkers []*handler.Checker
+	// 클라이언트가 보낸 값을 신뢰하지 않고 항상 제거하는 헤더
+	trustedHeaders []string
+	// 라우트 auth를 검증한 store. 인증도 이 store로 해서 reload 중에도
+	// 라우트, provider, trustedHeaders가 같은 설정에서 온다
+	store auth.Store
 }
 
 type Route struct {
-	Prefix   string `yaml:"prefix"`
-	Target   string `yaml:"target"`
-	AuthType string `yaml:"auth"`
+	Prefix   string            `yaml:"prefix"`
+	Target   string            `yaml:"target"`
+	Targets  []TargetConfig    `yaml:"targets"`
+	Strategy string            `yaml:"strategy"`
+	Auth     auth.Requirement  `yaml:"auth"`
+	Host     string            `yaml:"host"`
+	Methods  []string          `yaml:"methods"`
+	Headers  map[string]string `yaml:"headers"`
+
+	auth.Policy `yaml:",inline"`
+
+	StripPrefix *bool         `yaml:"strip_prefix"`
+	AddPrefix   string        `yaml:"add_prefix"`
+	Rewrite     []RewriteRule `yaml:"rewrite"`
+
+	HealthCheck    *handler.HealthCheck `yaml:"health_check"`
+	RateLimit      *ratelimit.Config    `yaml:"rate_limit"`
+	CircuitBreaker *breaker.Config      `yaml:"circuit_breaker"`
+	Timeouts       *Timeouts            `yaml:"timeouts"`
+	Transport      *TransportConfig     `yaml:"transport"`
+	Retry          *RetryPolicy         `yaml:"retry"`
+
+	balancer  balancer.Balancer
+	limiter   *ratelimit.Limiter
+	transport *http.Transport
+}
+
+type TargetConfig struct {
+	URL    string `yaml:"url"`
+	Weight int    `yaml:"weight"`
+}
+
+// Match is the result of routing a single request. Target and URL are set
+// by Pick, which also takes the target's circuit breaker slot; Observe or
+// Abandon settles it, and Done must be called once the request has finished.
+// Transport is nil for routes using the default transport and Timeout is
+// zero when the route has no overall request deadline.
+type Match struct {
+	// Route identifies the matched route as host and prefix, for logs.
+	Route       string
+	URL         string
+	Auth        auth.Requirement
+	Target      *balancer.Target
+	RateLimiter *ratelimit.Limiter
+	Transport   http.RoundTripper
+	Timeout     time.Duration
+	Retry       *RetryPolicy
+	Policy      *auth.Policy
+
+	store   auth.Store
+	route   *Route
+	path    string
+	settled atomic.Bool
+}
+
+// Authenticate runs the route's auth against r with the providers of the
+// config the route was loaded from.
+func (m *Match) Authenticate(r *http.Request) error {
+	return m.Auth.Authenticate(m.store, r)
+}
+
+// Challenges returns the WWW-Authenticate values for a failed Authenticate.
+func (m *Match) Challenges(err error) []string {
+	return m.Auth.Challenges(m.store, err)
+}
+
+// Pick chooses the upstream target and sets Target and URL. It is called
+// after auth and rate limiting, so rejected requests never hold a target
+// or a half-open probe slot. It returns ErrNoAvailableTarget when every
+// target is unhealthy, ejected or refused by its circuit breaker.
+func (m *Match) Pick() error {
+	if m.route == nil || m.Target != nil {
+		return nil
+	}
+	target := m.route.pick()
+	if target == nil {
+		return ErrNoAvailableTarget
+	}
+	target.Acquire()
+	m.Target = target
+	m.URL = joinURL(target.URL, m.route.upstreamPath(m.path))
+	return nil
+}
+
+// Observe records the outcome of the upstream request on the target and
+// reports whether the target was ejected because of it.
+func (m *Match) Observe(success bool, latency time.Duration) bool {
+	if m.Target == nil || !m.settled.CompareAndSwap(false, true) {
+		return false
+	}
+	return m.Target.Observe(success, latency)
+}
+
+// Abandon gives back the circuit breaker slot when the outcome is unknown.
+func (m *Match) Abandon() {
+	if m.Target != nil && m.settled.CompareAndSwap(false, true) {
+		m.Target.Abandon()
+	}
+}
+
+func (m *Match) Done() {
+	// 인증 실패 등으로 upstream에 보내지 않은 요청도 breaker slot을 돌려준다
+	m.Abandon()
+	if m.Target != nil {
+		m.Target.Release()
+	}
 }
 
 func NewRouter(data []byte) (*Router, error) {
+	return NewRouterWithAuth(data, auth.Current())
+}
+
+// NewRouterWithAuth validates route auth types against store instead of the
+// active auth store, so a new config can be checked before it is installed.
+func NewRouterWithAuth(data []byte, store auth.Store) (*Router, error) {
 	var config struct {
-		Routes []Route `yaml:"routes"`
+		Routes         []Route  `yaml:"routes"`
+		TrustedHeaders []string `yaml:"trusted_headers"`
 	}
 
 	err := yaml.Unmarshal(data, &config)
@@ -34,78 +165,322 @@ func NewRouter(data []byte) (*Router, error) {
 		return nil, fmt.Errorf("failed to parse yaml: %w", err)
 	}
 
+	trustedHeaders := append(auth.InjectedHeaders(store), config.TrustedHeaders...)
+	trusted := make(map[string]bool, len(trustedHeaders))
+	for _, header := range trustedHeaders {
+		trusted[http.CanonicalHeaderKey(header)] = true
+	}
+
 	seen := make(map[string]bool)
 	for _, route := range config.Routes {
-		if route.Prefix == "" || route.Target == "" {
+		if route.Prefix == "" || (route.Target == "" && len(route.Targets) == 0) {
 			return nil, fmt.Errorf("invalid route: prefix=%q target=%q",
 				route.Prefix, route.Target)
 		}
-		if !isHTTPScheme(route.Target) {
-			return nil, fmt.Errorf("target is not http scheme: target=%q", route.Target)
+		if route.Target != "" && len(route.Targets) > 0 {
+			return nil, fmt.Errorf("route must set either target or targets: prefix=%q", route.Prefix)
+		}
+		for _, target := range route.targetConfigs() {
+			if !isHTTPScheme(target.URL) {
+				return nil, fmt.Errorf("target is not http scheme: target=%q", target.URL)
+			}
+			if target.Weight < 0 {
+				return nil, fmt.Errorf("target weight must not be negative: target=%q", target.URL)
+			}
+		}
+		if route.CircuitBreaker != nil {
+			if err := route.CircuitBreaker.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid circuit breaker: prefix=%q: %w", route.Prefix, err)
+			}
+		}
+		if route.Retry != nil {
+			if err := route.Retry.validate(); err != nil {
+				return nil, fmt.Errorf("invalid retry: prefix=%q: %w", route.Prefix, err)
+			}
+		}
+		for name := range route.Headers {
+			if trusted[http.CanonicalHeaderKey(name)] {
+				return nil, fmt.Errorf("route headers must not match trusted header %q: prefix=%q", name, route.Prefix)
+			}
+		}
+		if !isValidHost(route.Host) {
+			return nil, fmt.Errorf("invalid route host: %q", route.Host)
 		}
-		if seen[normalize(route.Prefix)] {
+		key := matchKey(route)
+		if seen[key] {
 			return nil, fmt.Errorf("duplicate route prefix: %q", route.Prefix)
 		}
-		seen[normalize(route.Prefix)] = true
+		seen[key] = true
 
-		authType := route.AuthType
-		if authType != "" {
-			proxy := auth.Get(authType)
-			if proxy == nil {
-				return nil, fmt.Errorf("AuthProxy is not setting %s", authType)
+		if route.Auth.Mode != "" {
+			if err := route.Auth.Validate(store); err != nil {
+				return nil, fmt.Errorf("invalid route auth: prefix=%q: %w", route.Prefix, err)
+			}
+		}
+		if !route.Policy.IsEmpty() {
+			if route.Auth.IsEmpty() {
+				return nil, fmt.Errorf("route roles need auth: prefix=%q", route.Prefix)
+			}
+			if err := route.Policy.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid route authorization: prefix=%q: %w", route.Prefix, err)
+			}
+			if len(route.AllowIdentities) > 0 && !route.Auth.Uses(store, auth.MTLS) {
+				return nil, fmt.Errorf("route allow_identities need mtls auth: prefix=%q", route.Prefix)
 			}
 		}
 	}
 
 	routesCopy := make([]Route, len(config.Routes))
+	var checkers []*handler.Checker
 	for i, route := range config.Routes {
+		strategy, err := balancer.ParseStrategy(route.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		configs := route.targetConfigs()
+		targets := make([]*balancer.Target, len(configs))
+		for j, target := range configs {
+			targets[j] = balancer.NewTarget(normalizeSuffix(target.URL), target.Weight)
+			if route.HealthCheck != nil && route.HealthCheck.Passive != nil {
+				targets[j].SetEjection(route.HealthCheck.Passive.Ejection())
+			}
+			if route.CircuitBreaker != nil {
+				name := normalizeHost(route.Host) + normalize(route.Prefix) + " -> " + targets[j].URL
+				targets[j].SetBreaker(breaker.New(name, *route.CircuitBreaker))
+			}
+		}
+		lb, err := balancer.New(strategy, targets)
+		if err != nil {
+			return nil, err
+		}
+		transport, err := newTransport(route.Timeouts, route.Transport)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transport: prefix=%q: %w", route.Prefix, err)
+		}
+		if route.Retry != nil {
+			retry := route.Retry.withDefaults()
+			route.Retry = &retry
+		}
+		rewrites, err := compileRewrites(route.Rewrite)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route: prefix=%q: %w", route.Prefix, err)
+		}
+		addPrefix := route.AddPrefix
+		if addPrefix != "" {
+			addPrefix = normalize(addPrefix)
+		}
+		var limiter *ratelimit.Limiter
+		if route.RateLimit != nil {
+			limiter, err = ratelimit.New(*route.RateLimit)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit: prefix=%q: %w", route.Prefix, err)
+			}
+		}
+
 		routesCopy[i] = Route{
-			Prefix: normalize(route.Prefix),
-			Target: normalizeSuffix(route.Target),
+			Prefix:         normalize(route.Prefix),
+			Target:         normalizeSuffix(route.Target),
+			Auth:           route.Auth,
+			Policy:         route.Policy,
+			Targets:        route.Targets,
+			Strategy:       string(strategy),
+			Host:           normalizeHost(route.Host),
+			Methods:        normalizeMethods(route.Methods),
+			Headers:        normalizeHeaders(route.Headers),
+			StripPrefix:    route.StripPrefix,
+			AddPrefix:      addPrefix,
+			Rewrite:        rewrites,
+			HealthCheck:    route.HealthCheck,
+			RateLimit:      route.RateLimit,
+			CircuitBreaker: route.CircuitBreaker,
+			Timeouts:       route.Timeouts,
+			Transport:      route.Transport,
+			Retry:          route.Retry,
+
+			balancer:  lb,
+			limiter:   limiter,
+			transport: transport,
+		}
+		if route.HealthCheck != nil && route.HealthCheck.Active != nil {
+			checkers = append(checkers,
+				handler.NewChecker(routesCopy[i].name(), *route.HealthCheck.Active, targets))
 		}
 	}
 
-	sort.Slice(routesCopy, func(i, j int) bool {
-		return len(routesCopy[i].Prefix) > len(routesCopy[j].Prefix)
+	sort.SliceStable(routesCopy, func(i, j int) bool {
+		return precedes(routesCopy[i], routesCopy[j])
 	})
 
-	return &Router{routes: routesCopy}, nil
+	return &Router{routes: routesCopy, checkers: checkers, trustedHeaders: trustedHeaders, store: store}, nil
+}
+
+// Start begins active health checking of route targets.
+func (r *Router) Start() {
+	for _, c := range r.checkers {
+		c.Start()
+	}
+}
+
+// Close stops the health checks started by Start and releases idle
+// upstream connections.
+func (r *Router) Close() {
+	for _, c := range r.checkers {
+		c.Stop()
+	}
+	for _, route := range r.routes {
+		if route.transport != nil {
+			route.transport.CloseIdleConnections()
+		}
+	}
+}
+
+// Upstreams reports the health of every route target.
+func (r *Router) Upstreams() []handler.UpstreamStatus {
+	var result []handler.UpstreamStatus
+	for _, route := range r.routes {
+		for _, target := range route.balancer.Targets() {
+			result = append(result, handler.UpstreamStatus{
+				Route:   route.name(),
+				Target:  target.URL,
+				Status:  target.Status(),
+				Circuit: target.Circuit(),
+				Active:  target.Active(),
+			})
+		}
+	}
+	return result
 }
 
-func (r *Router) Route(path string) (string, string, bool) {
-	normalizationPath := normalize(path)
-	route, ok := r.matchRoute(normalizationPath)
+// Route finds the route for req and returns ErrNotFound when none
+// matches. The upstream target is not chosen until Match.Pick.
+// Trusted headers are removed from req before matching, so a client cannot
+// pick a route with a spoofed identity header.
+// Routes are matched against the decoded path, and the upstream path keeps
+// the client's percent-encoding and trailing slash. The client's query
+// string is not part of Match.URL; it is merged in by the proxy.
+func (r *Router) Route(req *http.Request) (*Match, error) {
+	// 인증 여부와 관계없이 클라이언트가 보낸 사용자 정보 헤더는 신뢰하지 않는다
+	for _, header := range r.trustedHeaders {
+		req.Header.Del(header)
+	}
+
+	// 라우트 선택은 디코딩된 경로로 한다. 인코딩된 경로로 고르면 %61dmin 같은
+	// 요청이 인증이 걸린 더 구체적인 라우트를 건너뛸 수 있다
+	path := normalizePrefix(req.URL.Path)
+	route, ok := r.matchRoute(req, normalize(path))
 	if !ok {
-		return "", "", false
+		return nil, ErrNotFound
 	}
 
-	target := route.Target
-	if route.Prefix == root {
-		return target + normalizationPath, route.AuthType, true
+	// upstream에는 클라이언트가 보낸 인코딩을 유지하되, 인코딩된 경로가 매칭된
+	// prefix로 그대로 시작하지 않으면 매칭에 쓴 디코딩 경로를 보낸다
+	escapedPath := normalizePrefix(req.URL.EscapedPath())
+	if !route.matchPath(normalize(escapedPath)) {
+		escapedPath = (&url.URL{Path: path}).EscapedPath()
 	}
 
-	after := normalizationPath[len(route.Prefix):]
-	return target + after, route.AuthType, true
+	match := &Match{
+		Route:       route.Host + route.Prefix,
+		Auth:        route.Auth,
+		RateLimiter: route.limiter,
+		store:       r.store,
+		route:       &route,
+		path:        escapedPath,
+	}
+	if route.transport != nil {
+		match.Transport = route.transport
+	}
+	if route.Timeouts != nil {
+		match.Timeout = route.Timeouts.Request
+	}
+	match.Retry = route.Retry
+	if !route.Policy.IsEmpty() {
+		match.Policy = &route.Policy
+	}
+	return match, nil
 }
 
-func (r Router) matchRoute(path string) (Route, bool) {
+func (r Router) matchRoute(req *http.Request, path string) (Route, bool) {
+	host := requestHost(req)
 	for i := range r.routes {
 		route := r.routes[i]
-		if strings.HasPrefix(path, route.Prefix) {
-			remainder := path[len(route.Prefix):]
-
-			if len(remainder) == 0 {
-				return route, true
-			}
-
-			if strings.HasPrefix(remainder, pathSeparator) {
-				return route, true
-			}
+		if !route.matchPath(path) {
+			continue
+		}
+		if matchHost(route.Host, host) && route.matchMethod(req.Method) && route.matchHeaders(req.Header) {
+			return route, true
 		}
 	}
 	return Route{}, false
 }
 
+// pick chooses a target and takes its circuit breaker slot. The balancer
+// skips targets whose breaker would refuse, but another request can take
+// the last half-open probe slot in between, so the next target is tried
+// before giving up.
+func (route Route) pick() *balancer.Target {
+	targets := route.balancer.Targets()
+	for range targets {
+		target := route.balancer.Next()
+		if target == nil {
+			break
+		}
+		if target.Allow() {
+			return target
+		}
+	}
+	for _, target := range targets {
+		if target.CircuitRefuses() {
+			metrics.CircuitRejected.With(route.name()).Inc()
+			break
+		}
+	}
+	return nil
+}
+
+// name identifies the route in logs and health reports.
+func (route Route) name() string {
+	return route.Host + route.Prefix
+}
+
+// targetConfigs returns the configured upstreams, treating a single
+// target as a one-element list.
+func (route Route) targetConfigs() []TargetConfig {
+	if route.Target != "" {
+		return []TargetConfig{{URL: route.Target, Weight: 1}}
+	}
+	return route.Targets
+}
+
+func (route Route) matchPath(path string) bool {
+	if !strings.HasPrefix(path, route.Prefix) {
+		return false
+	}
+	remainder := path[len(route.Prefix):]
+	return len(remainder) == 0 || route.Prefix == root || strings.HasPrefix(remainder, pathSeparator)
+}
+
+// joinURL appends path to target, merging any query string of the target
+// with one produced by a rewrite rule.
+func joinURL(target, path string) string {
+	base, targetQuery, _ := strings.Cut(target, "?")
+	path, pathQuery, _ := strings.Cut(path, "?")
+	query := JoinQuery(targetQuery, pathQuery)
+	if query == "" {
+		return base + path
+	}
+	return base + path + "?" + query
+}
+
+// JoinQuery concatenates raw query strings, keeping repeated keys and
+// their order.
+func JoinQuery(first, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+	return first + "&" + second
+}
+
 func normalizeSuffix(path string) string {
 	if path == root {
 		return path
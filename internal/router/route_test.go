@@ -5,9 +5,11 @@ import (
 	"gateway-go/internal/auth"
 	"gateway-go/internal/logger"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M) {
@@ -20,6 +22,21 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// routeTo routes req and picks its target, as the proxy does once the
+// request has passed auth.
+func routeTo(router interface {
+	Route(*http.Request) (*Match, error)
+}, req *http.Request) (*Match, error) {
+	match, err := router.Route(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := match.Pick(); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
 func TestValidRouter(t *testing.T) {
 	yml := `
 routes:
@@ -45,8 +62,12 @@ routes:
 		},
 	}
 
-	if !reflect.DeepEqual(router.routes, expected) {
-		t.Errorf("라우터가 잘못 생성되었습니다. got=%v, want=%v", router.routes, expected)
+	got := make([]Route, len(router.routes))
+	for i, route := range router.routes {
+		got[i] = Route{Prefix: route.Prefix, Target: route.Target}
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("라우터가 잘못 생성되었습니다. got=%v, want=%v", got, expected)
 	}
 }
 
@@ -117,16 +138,504 @@ routes:
 		t.Fatal("router create fail ", err)
 	}
 
-	targetPath, authType, ok := router.Route("/api/test/test/1")
-	if !ok {
-		t.Errorf("route실패")
+	match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/api/test/test/1", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+
+	if match.Auth.String() != "jwt" {
+		t.Fatal("잘못된 인증 타입 ", match.Auth)
+	}
+
+	if match.URL != "http://localhost:8080/1" {
+		t.Errorf("Routing 변환 실패: %s", match.URL)
+	}
+}
+
+func TestRouteMatchers(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    target : http://default:8080
+  - prefix : /api
+    target : http://wildcard:8080
+    host : "*.api.example.com"
+  - prefix : /api
+    target : http://exact:8080
+    host : tenant.api.example.com
+  - prefix : /api
+    target : http://delete:8080
+    methods : [delete]
+  - prefix : /api
+    target : http://canary:8080
+    headers :
+      x-canary : "true"
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		host     string
+		header   map[string]string
+		expected string
+	}{
+		{"default", http.MethodGet, "gateway.local", nil, "http://default:8080/users"},
+		{"wildcard host", http.MethodGet, "other.api.example.com:8443", nil, "http://wildcard:8080/users"},
+		{"wildcard does not match apex", http.MethodGet, "api.example.com", nil, "http://default:8080/users"},
+		{"exact host wins", http.MethodGet, "TENANT.api.example.com", nil, "http://exact:8080/users"},
+		{"method", http.MethodDelete, "gateway.local", nil, "http://delete:8080/users"},
+		{"header", http.MethodGet, "gateway.local", map[string]string{"X-Canary": "true"}, "http://canary:8080/users"},
+		{"header mismatch", http.MethodGet, "gateway.local", map[string]string{"X-Canary": "false"}, "http://default:8080/users"},
+		{"host wins over header", http.MethodGet, "a.api.example.com", map[string]string{"X-Canary": "true"}, "http://wildcard:8080/users"},
 	}
 
-	if authType != "" {
-		t.Fatal("잘못된 인증 타입 ", authType)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/users", nil)
+			req.Host = tt.host
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			match, err := routeTo(router, req)
+			if err != nil {
+				t.Fatal("route실패 ", err)
+			}
+			if match.URL != tt.expected {
+				t.Errorf("Routing 변환 실패: got=%s, want=%s", match.URL, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTrustedHeaderMatchers(t *testing.T) {
+	tests := map[string]string{
+		"identity header": `
+routes:
+  - prefix : /admin
+    target : http://admin:8080
+    headers :
+      X-User-Role : ADMIN
+`,
+		"configured trusted header": `
+trusted_headers : [X-Tenant-Id]
+routes:
+  - prefix : /api
+    target : http://tenant:8080
+    headers :
+      x-tenant-id : a
+`,
+	}
+	for name, yml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRouter([]byte(yml)); err == nil {
+				t.Error("신뢰 헤더로 라우트를 고르는 설정 허용")
+			}
+		})
+	}
+
+	router, err := NewRouter([]byte(`
+trusted_headers : [X-Tenant-Id]
+routes:
+  - prefix : /api
+    target : http://default:8080
+`))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Set("X-User-Role", "ADMIN")
+	req.Header.Set("X-Tenant-Id", "other")
+	match, err := routeTo(router, req)
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	match.Done()
+	if req.Header.Get("X-User-Role") != "" || req.Header.Get("X-Tenant-Id") != "" {
+		t.Errorf("라우팅 전에 신뢰 헤더가 제거되지 않음: %v", req.Header)
+	}
+}
+
+func TestRootPrefixRoute(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /
+    target : http://localhost:8081
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/any/path", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	if match.URL != "http://localhost:8081/any/path" {
+		t.Errorf("Routing 변환 실패: %s", match.URL)
+	}
+}
+
+func TestInvalidHostRouter(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    target : http://localhost:8081
+    host : "api.*.example.com"
+`
+
+	_, err := NewRouter([]byte(yml))
+	if err == nil {
+		t.Errorf("host 검증 로직 검증 실패")
+	}
+}
+
+func TestWeightedTargets(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    strategy : weighted-round-robin
+    targets :
+      - url : http://a:8080/
+        weight : 3
+      - url : http://b:8080
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+		if err != nil {
+			t.Fatal("route실패 ", err)
+		}
+		counts[match.URL]++
+		match.Done()
+		if match.Target.Active() != 0 {
+			t.Errorf("연결 수 반환 실패: %d", match.Target.Active())
+		}
+	}
+
+	if counts["http://a:8080/users"] != 6 || counts["http://b:8080/users"] != 2 {
+		t.Errorf("가중치 분배 실패: %v", counts)
+	}
+}
+
+func TestSkipRefusingCircuit(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    targets :
+      - url : http://a:8080
+      - url : http://b:8080
+    circuit_breaker:
+      min_requests: 1
+      open_duration: 10ms
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	route := func() *Match {
+		match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+		if err != nil {
+			t.Fatal("route실패 ", err)
+		}
+		return match
+	}
+
+	// a의 breaker를 열고 cool-down 후 probe slot을 차지한다
+	for {
+		match := route()
+		if match.URL == "http://a:8080/users" {
+			match.Observe(false, 0)
+			match.Done()
+			break
+		}
+		match.Done()
+	}
+	time.Sleep(20 * time.Millisecond)
+	probe := route()
+	if probe.URL != "http://a:8080/users" {
+		match := probe
+		probe = route()
+		match.Done()
+	}
+	if probe.URL != "http://a:8080/users" {
+		t.Fatalf("half-open probe 선택 실패: %s", probe.URL)
+	}
+
+	for i := 0; i < 4; i++ {
+		match := route()
+		if match.URL != "http://b:8080/users" {
+			t.Errorf("probe slot이 찬 target 선택: %s", match.URL)
+		}
+		match.Done()
+	}
+
+	// 응답 없이 끝난 probe도 slot을 돌려준다
+	probe.Done()
+	if !probe.Target.Available() {
+		t.Errorf("probe slot 반환 실패: %s", probe.Target.Circuit())
+	}
+}
+
+func TestInvalidTargetsRouter(t *testing.T) {
+	tests := map[string]string{
+		"target and targets": `
+routes:
+  - prefix : /api
+    target : http://a:8080
+    targets :
+      - url : http://b:8080
+`,
+		"unknown strategy": `
+routes:
+  - prefix : /api
+    strategy : fastest
+    targets :
+      - url : http://b:8080
+`,
+		"not http": `
+routes:
+  - prefix : /api
+    targets :
+      - url : ftp://b:8080
+`,
 	}
 
-	if targetPath != "http://localhost:8080/1" {
-		t.Errorf("Routing 변환 실패: %s", targetPath)
+	for name, yml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRouter([]byte(yml)); err == nil {
+				t.Errorf("targets 검증 로직 검증 실패")
+			}
+		})
+	}
+}
+
+func TestNewRouterWithAuth(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    target : http://localhost:8081
+    auth: basic-test
+`
+
+	if _, err := NewRouterWithAuth([]byte(yml), auth.Store{}); err == nil {
+		t.Fatal("등록되지 않은 인증 타입 검증 실패")
+	}
+
+	store := auth.Store{"BASIC-TEST": MockProxy{AuthType: "BASIC-TEST"}}
+	if _, err := NewRouterWithAuth([]byte(yml), store); err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	if auth.Get("basic-test") != nil {
+		t.Error("검증용 store가 전역 store에 반영됨")
+	}
+
+	chained := `
+routes:
+  - prefix : /api
+    target : http://localhost:8081
+    auth:
+      any: [basic-test, api-test]
+`
+	if _, err := NewRouterWithAuth([]byte(chained), store); err == nil {
+		t.Fatal("등록되지 않은 인증 타입이 포함된 체인 검증 실패")
+	}
+	store["API-TEST"] = MockProxy{AuthType: "API-TEST"}
+	if _, err := NewRouterWithAuth([]byte(chained), store); err != nil {
+		t.Fatal("router create fail ", err)
+	}
+}
+
+func TestRouterUsesItsAuthStore(t *testing.T) {
+	store := auth.Store{"JWT": MockProxy{AuthType: auth.JWT}}
+	router, err := NewRouterWithAuth([]byte("routes:\n  - prefix: /api\n    target: http://localhost:8081\n    auth: jwt\n"), store)
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	// reload 중 전역 store가 먼저 바뀌어도 라우트는 자신이 검증된 store로 인증한다
+	previous := auth.Replace(auth.Store{"JWT": MockProxy{AuthType: auth.JWT, ErrorMake: true}})
+	defer auth.Replace(previous)
+
+	match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	defer match.Done()
+	if err := match.Authenticate(httptest.NewRequest(http.MethodGet, "/api", nil)); err != nil {
+		t.Errorf("라우터의 auth store가 아닌 전역 store로 인증함: %v", err)
+	}
+}
+
+func TestSwappableRouter(t *testing.T) {
+	first, err := NewRouter([]byte("routes:\n  - prefix: /api\n    target: http://first:8080\n"))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	second, err := NewRouter([]byte("routes:\n  - prefix: /api\n    target: http://second:8080\n"))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	swappable := NewSwappable(first)
+	before, err := routeTo(swappable, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	if old := swappable.Swap(second); old != first {
+		t.Error("이전 router 반환 실패")
+	}
+	after, err := routeTo(swappable, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+
+	if before.URL != "http://first:8080" || after.URL != "http://second:8080" {
+		t.Errorf("router 교체 실패: before=%s after=%s", before.URL, after.URL)
+	}
+}
+
+func TestRouteTransport(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    target : http://localhost:8081
+    timeouts :
+      dial : 1s
+      request : 5s
+    transport :
+      max_idle_conns_per_host : 7
+  - prefix : /default
+    target : http://localhost:8082
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	match, err := routeTo(router, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	transport, ok := match.Transport.(*http.Transport)
+	if !ok || transport.MaxIdleConnsPerHost != 7 {
+		t.Errorf("route transport 설정 실패: %v", match.Transport)
+	}
+	if match.Timeout != 5*time.Second {
+		t.Errorf("request timeout 불일치: %v", match.Timeout)
+	}
+
+	match, err = routeTo(router, httptest.NewRequest(http.MethodGet, "/default", nil))
+	if err != nil {
+		t.Fatal("route실패 ", err)
+	}
+	if match.Transport != nil || match.Timeout != 0 {
+		t.Errorf("기본 transport 사용 실패: %v %v", match.Transport, match.Timeout)
+	}
+}
+
+func TestInvalidTransportRouter(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /api
+    target : http://localhost:8081
+    transport :
+      tls :
+        ca_file : /does/not/exist.pem
+`
+
+	if _, err := NewRouter([]byte(yml)); err == nil {
+		t.Error("transport 검증 로직 검증 실패")
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.withDefaults()
+
+	if !policy.AllowsMethod(http.MethodGet) || policy.AllowsMethod(http.MethodPost) {
+		t.Error("멱등 메서드 판별 실패")
+	}
+	if !policy.Retryable(&http.Response{StatusCode: http.StatusBadGateway}, nil) ||
+		policy.Retryable(&http.Response{StatusCode: http.StatusInternalServerError}, nil) {
+		t.Error("재시도 status 판별 실패")
+	}
+	if !policy.Retryable(nil, errors.New("connection reset")) {
+		t.Error("네트워크 에러 재시도 실패")
+	}
+
+	for retry, limit := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 6: 300} {
+		limit *= time.Millisecond
+		backoff := policy.BackoffFor(retry)
+		if backoff < limit/2 || backoff > limit {
+			t.Errorf("backoff 범위 초과: retry=%d backoff=%v", retry, backoff)
+		}
+	}
+}
+
+func TestPathRewrite(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /keep
+    target : http://keep:8080
+    strip_prefix : false
+  - prefix : /add
+    target : http://add:8080
+    add_prefix : /internal/
+  - prefix : /v1
+    target : http://users:8080
+    rewrite :
+      - match : ^/v1/users/([^/]+)$
+        replace : /users?id=$1
+      - match : ^/v1/(?P<rest>.*)$
+        replace : /api/${rest}
+`
+
+	router, err := NewRouter([]byte(yml))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+
+	tests := map[string]string{
+		"/keep/a":      "http://keep:8080/keep/a",
+		"/add/a":       "http://add:8080/internal/a",
+		"/add":         "http://add:8080/internal",
+		"/v1/users/42": "http://users:8080/users?id=42",
+		"/v1/orders/1": "http://users:8080/api/orders/1",
+	}
+	for path, expected := range tests {
+		match, err := routeTo(router, httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal("route실패 ", err)
+		}
+		if match.URL != expected {
+			t.Errorf("Routing 변환 실패: path=%s got=%s want=%s", path, match.URL, expected)
+		}
+	}
+}
+
+func TestInvalidRewriteRouter(t *testing.T) {
+	yml := `
+routes:
+  - prefix : /v1
+    target : http://users:8080
+    rewrite :
+      - match : "^/v1/(["
+        replace : /users
+`
+
+	if _, err := NewRouter([]byte(yml)); err == nil {
+		t.Error("rewrite 패턴 검증 실패")
 	}
 }
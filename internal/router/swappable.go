@@ -0,0 +1,39 @@
+package router
+
+import (
+	handler "gateway-go/internal/health"
+	"net/http"
+	"sync/atomic"
+)
+
+// Swappable is a Router whose routes can be replaced while requests are
+// being served. Requests already routed keep using the router that
+// matched them.
+type Swappable struct {
+	current atomic.Pointer[Router]
+}
+
+func NewSwappable(r *Router) *Swappable {
+	s := &Swappable{}
+	s.current.Store(r)
+	return s
+}
+
+func (s *Swappable) Route(req *http.Request) (*Match, error) {
+	return s.current.Load().Route(req)
+}
+
+func (s *Swappable) Upstreams() []handler.UpstreamStatus {
+	return s.current.Load().Upstreams()
+}
+
+// Swap installs r and returns the previous router, which the caller
+// should Close once it is no longer needed.
+func (s *Swappable) Swap(r *Router) *Router {
+	return s.current.Swap(r)
+}
+
+// Close stops the health checks of the current router.
+func (s *Swappable) Close() {
+	s.current.Load().Close()
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Timeouts is the per-route timeouts block in config.yml.
+// Request bounds the whole upstream exchange including the response body.
+type Timeouts struct {
+	Dial           time.Duration `yaml:"dial"`
+	TLSHandshake   time.Duration `yaml:"tls_handshake"`
+	ResponseHeader time.Duration `yaml:"response_header"`
+	Request        time.Duration `yaml:"request"`
+}
+
+// TransportConfig is the per-route transport block in config.yml.
+type TransportConfig struct {
+	MaxIdleConnsPerHost int        `yaml:"max_idle_conns_per_host"`
+	HTTP2               *bool      `yaml:"http2"`
+	TLS                 *TLSConfig `yaml:"tls"`
+}
+
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+func (t Timeouts) validate() error {
+	if t.Dial < 0 || t.TLSHandshake < 0 || t.ResponseHeader < 0 || t.Request < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	return nil
+}
+
+// newTransport builds the RoundTripper of a route. Routes without timeouts
+// or transport settings share http.DefaultTransport and get nil here.
+func newTransport(timeouts *Timeouts, config *TransportConfig) (*http.Transport, error) {
+	if timeouts == nil && config == nil {
+		return nil, nil
+	}
+	if timeouts == nil {
+		timeouts = &Timeouts{}
+	}
+	if config == nil {
+		config = &TransportConfig{}
+	}
+	if err := timeouts.validate(); err != nil {
+		return nil, err
+	}
+	if config.MaxIdleConnsPerHost < 0 {
+		return nil, errors.New("max_idle_conns_per_host must not be negative")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if timeouts.Dial > 0 {
+		dialer := &net.Dialer{
+			Timeout:   timeouts.Dial,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if timeouts.TLSHandshake > 0 {
+		transport.TLSHandshakeTimeout = timeouts.TLSHandshake
+	}
+	transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.HTTP2 != nil && !*config.HTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+func (t TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca_file: %q", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
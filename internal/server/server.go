@@ -0,0 +1,107 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultAddress = ":8080"
+
+// Config is the server section of config.yml. It is read once at startup;
+// changing it needs a restart. /metrics and the upstream details of
+// /health are only served on AdminAddress, so they never share a listener
+// with the proxied routes; without it they are not exposed.
+type Config struct {
+	Address      string     `yaml:"address"`
+	AdminAddress string     `yaml:"admin_address"`
+	TLS          *TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig terminates TLS with CertFile/KeyFile. When ClientCAFile is set,
+// client certificates are verified against it; ClientAuth is one of none,
+// optional (the default) or require.
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	ClientAuth   string `yaml:"client_auth"`
+}
+
+func ReadConfig(data []byte) (Config, error) {
+	var root struct {
+		Server Config `yaml:"server"`
+	}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Config{}, err
+	}
+	config := root.Server
+	if config.Address == "" {
+		config.Address = defaultAddress
+	}
+	if config.AdminAddress == config.Address {
+		return Config{}, fmt.Errorf("admin_address must differ from address: %q", config.Address)
+	}
+	return config, nil
+}
+
+// Build loads the certificates and returns the listener's tls.Config.
+func (c TLSConfig) Build() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("tls needs cert_file and key_file")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	clientAuth, err := parseClientAuth(c.ClientAuth, c.ClientCAFile != "")
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth == tls.NoClientCert {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in client ca: %q", c.ClientCAFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = clientAuth
+	return config, nil
+}
+
+func parseClientAuth(value string, hasCA bool) (tls.ClientAuthType, error) {
+	switch value {
+	case "":
+		if hasCA {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "optional", "require":
+		if !hasCA {
+			return tls.NoClientCert, fmt.Errorf("client_auth %s needs client_ca_file", value)
+		}
+		if value == "require" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client_auth: %q", value)
+	}
+}
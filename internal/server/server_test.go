@@ -0,0 +1,166 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return testCA{cert: cert, key: key}
+}
+
+func (ca testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	config, err := ReadConfig([]byte("routes: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != ":8080" || config.TLS != nil {
+		t.Errorf("기본 설정 불일치: %+v", config)
+	}
+
+	config, err = ReadConfig([]byte("server:\n  address: \":8443\"\n  tls:\n    cert_file: a.pem\n    key_file: a.key\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != ":8443" || config.TLS == nil || config.TLS.CertFile != "a.pem" {
+		t.Errorf("server 설정 불일치: %+v", config)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "client-ca")
+	serverCert := ca.issue(t, "gateway", x509.ExtKeyUsageServerAuth)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := TLSConfig{
+		CertFile:     writePEM(t, dir, "server.pem", "CERTIFICATE", serverCert.Certificate[0]),
+		KeyFile:      writePEM(t, dir, "server.key", "PRIVATE KEY", keyDER),
+		ClientCAFile: writePEM(t, dir, "ca.pem", "CERTIFICATE", ca.cert.Raw),
+		ClientAuth:   "require",
+	}
+	tlsConfig, err := config.Build()
+	if err != nil {
+		t.Fatal("tls config build fail ", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+
+	resp, err := client(ca.issue(t, "billing", x509.ExtKeyUsageClientAuth)).Get(server.URL)
+	if err != nil {
+		t.Fatal("mTLS 요청 실패 ", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("상태 코드 불일치: %d", resp.StatusCode)
+	}
+
+	if _, err := client().Get(server.URL); err == nil {
+		t.Error("client certificate 없는 요청이 허용되었습니다")
+	}
+
+	other := newTestCA(t, "other-ca")
+	if _, err := client(other.issue(t, "billing", x509.ExtKeyUsageClientAuth)).Get(server.URL); err == nil {
+		t.Error("다른 CA가 발급한 certificate가 허용되었습니다")
+	}
+}
+
+func TestClientAuthValidation(t *testing.T) {
+	invalid := []struct {
+		value string
+		hasCA bool
+	}{
+		{"require", false},
+		{"optional", false},
+		{"always", true},
+	}
+	for _, tt := range invalid {
+		if _, err := parseClientAuth(tt.value, tt.hasCA); err == nil {
+			t.Errorf("잘못된 client_auth가 허용되었습니다: %s", tt.value)
+		}
+	}
+}
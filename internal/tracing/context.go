@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+	flagSampled       = 0x01
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext is the part of a span that crosses process boundaries in the
+// W3C traceparent and tracestate headers.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Extract reads the trace context of an incoming request. It returns an
+// invalid SpanContext when the request has none or it is malformed.
+func Extract(h http.Header) SpanContext {
+	sc, ok := parseTraceparent(h.Get(traceparentHeader))
+	if !ok {
+		return SpanContext{}
+	}
+	sc.TraceState = strings.Join(h.Values(tracestateHeader), ",")
+	return sc
+}
+
+// Inject writes sc to the headers of an outgoing request.
+func Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(traceparentHeader, formatTraceparent(sc))
+	if sc.TraceState != "" {
+		h.Set(tracestateHeader, sc.TraceState)
+	} else {
+		h.Del(tracestateHeader)
+	}
+}
+
+// parseTraceparent accepts version 00 and, as the spec asks, the first four
+// fields of any later version.
+func parseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) || !decodeHex(parts[3], flags[:]) {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&flagSampled != 0
+	return sc, true
+}
+
+func formatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// decodeHex decodes lower case hex of exactly len(dst) bytes.
+func decodeHex(s string, dst []byte) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		fillRandom(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		fillRandom(id[:])
+	}
+	return id
+}
+
+func fillRandom(b []byte) {
+	for i := range b {
+		b[i] = byte(rand.Uint32())
+	}
+}
@@ -0,0 +1,260 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway-go/internal/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultFlushInterval = 5 * time.Second
+	exportTimeout        = 10 * time.Second
+	maxBatchSize         = 512
+	maxQueueSize         = 2048
+	scopeName            = "gateway-go"
+)
+
+// Exporter sends finished spans in batches to an OTLP/HTTP collector using
+// the JSON encoding. Spans are dropped when the queue is full so a slow
+// collector never blocks requests; drops are counted and logged once per
+// flush interval.
+type Exporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	interval    time.Duration
+	client      *http.Client
+
+	queue     chan *Span
+	dropped   atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+func NewExporter(endpoint, serviceName string, headers map[string]string, interval time.Duration) *Exporter {
+	if interval == 0 {
+		interval = defaultFlushInterval
+	}
+	return &Exporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     headers,
+		interval:    interval,
+		client:      &http.Client{Timeout: exportTimeout},
+		queue:       make(chan *Span, maxQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (e *Exporter) Start() {
+	e.startOnce.Do(func() { go e.run() })
+}
+
+// Close stops the exporter after sending the spans still queued.
+func (e *Exporter) Close() {
+	e.closeOnce.Do(func() {
+		close(e.stop)
+		started := true
+		e.startOnce.Do(func() { started = false })
+		if started {
+			<-e.done
+		}
+	})
+}
+
+func (e *Exporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// reportDropped logs how many spans were dropped since the last report.
+func (e *Exporter) reportDropped() {
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		logger.App.Warn("Trace spans dropped, export queue is full", "spans", dropped)
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			logger.App.Warn("Failed to export trace spans", "endpoint", e.endpoint, "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			e.reportDropped()
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) >= maxBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					e.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *Exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLP/JSON 인코딩: id는 hex, 64비트 정수는 문자열로 보낸다
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const statusCodeError = 2
+
+func (e *Exporter) encode(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, span.encode())
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{stringAttr("service.name", e.serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+func (s *Span) encode() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoded := otlpSpan{
+		TraceId:           s.context.TraceID.String(),
+		SpanId:            s.context.SpanID.String(),
+		TraceState:        s.context.TraceState,
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent.IsValid() {
+		encoded.ParentSpanId = s.parent.String()
+	}
+	for _, attr := range s.attrs {
+		switch value := attr.value.(type) {
+		case string:
+			encoded.Attributes = append(encoded.Attributes, stringAttr(attr.key, value))
+		case int64:
+			text := strconv.FormatInt(value, 10)
+			encoded.Attributes = append(encoded.Attributes, otlpKeyValue{Key: attr.key, Value: otlpAnyValue{IntValue: &text}})
+		}
+	}
+	if s.failed {
+		encoded.Status = otlpStatus{Code: statusCodeError, Message: s.message}
+	}
+	return encoded
+}
+
+func stringAttr(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
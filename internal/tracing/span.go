@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+type Kind int
+
+// Kind values follow the OTLP SpanKind enum.
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+type attribute struct {
+	key   string
+	value any
+}
+
+// Span is one timed operation of a trace. Only sampled spans are exported;
+// the others still carry their context to the upstream.
+type Span struct {
+	tracer  *Tracer
+	name    string
+	kind    Kind
+	start   time.Time
+	parent  SpanID
+	context SpanContext
+
+	mu      sync.Mutex
+	end     time.Time
+	attrs   []attribute
+	failed  bool
+	message string
+	ended   bool
+}
+
+// Context returns the context to propagate to requests made within the span.
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+func (s *Span) SetString(key, value string) {
+	s.set(key, value)
+}
+
+func (s *Span) SetInt(key string, value int64) {
+	s.set(key, value)
+}
+
+func (s *Span) set(key string, value any) {
+	if !s.context.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attribute{key, value})
+}
+
+// SetError marks the span as failed.
+func (s *Span) SetError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.message = message
+}
+
+// End finishes the span and queues it for export. Calls after the first
+// are ignored.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.context.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.enqueue(s)
+	}
+}
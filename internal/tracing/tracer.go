@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServiceName = "gateway"
+
+// Config is the tracing section of config.yml. Without an endpoint the
+// gateway still propagates trace context but exports no spans.
+type Config struct {
+	Endpoint      string            `yaml:"endpoint"`
+	ServiceName   string            `yaml:"service_name"`
+	SampleRatio   *float64          `yaml:"sample_ratio"`
+	Headers       map[string]string `yaml:"headers"`
+	FlushInterval time.Duration     `yaml:"flush_interval"`
+}
+
+func ReadConfig(data []byte) (Config, error) {
+	var root struct {
+		Tracing Config `yaml:"tracing"`
+	}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Config{}, err
+	}
+	config := root.Tracing
+	if config.SampleRatio != nil && (*config.SampleRatio < 0 || *config.SampleRatio > 1) {
+		return Config{}, errors.New("tracing sample_ratio must be between 0 and 1")
+	}
+	if config.Endpoint != "" {
+		u, err := url.Parse(config.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("invalid tracing endpoint: %q", config.Endpoint)
+		}
+	}
+	if config.FlushInterval < 0 {
+		return Config{}, errors.New("tracing flush_interval must not be negative")
+	}
+	return config, nil
+}
+
+// Tracer starts spans and hands finished sampled spans to its exporter.
+type Tracer struct {
+	serviceName string
+	// ratio를 trace id 하위 8바이트와 비교할 임계값으로 저장한다
+	threshold uint64
+	exporter  *Exporter
+}
+
+// NewTracer builds a tracer for config. Start must be called before spans
+// are exported.
+func NewTracer(config Config) *Tracer {
+	ratio := 1.0
+	if config.SampleRatio != nil {
+		ratio = *config.SampleRatio
+	}
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	t := &Tracer{serviceName: serviceName, threshold: ratioThreshold(ratio)}
+	if config.Endpoint != "" {
+		t.exporter = NewExporter(config.Endpoint, serviceName, config.Headers, config.FlushInterval)
+	}
+	return t
+}
+
+func ratioThreshold(ratio float64) uint64 {
+	if ratio >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(ratio * math.MaxUint64)
+}
+
+func (t *Tracer) Start() {
+	if t.exporter != nil {
+		t.exporter.Start()
+	}
+}
+
+// Close flushes spans that were not exported yet.
+func (t *Tracer) Close() {
+	if t.exporter != nil {
+		t.exporter.Close()
+	}
+}
+
+// sample follows a sampled parent and otherwise keeps the configured ratio
+// of traces, decided by the trace id so every hop agrees.
+func (t *Tracer) sample(parent SpanContext, traceID TraceID) bool {
+	if parent.IsValid() {
+		return parent.Sampled
+	}
+	if t.exporter == nil {
+		return false
+	}
+	return binary.BigEndian.Uint64(traceID[8:]) <= t.threshold && t.threshold != 0
+}
+
+var (
+	current atomic.Pointer[Tracer]
+	mu      sync.Mutex
+)
+
+func init() {
+	current.Store(NewTracer(Config{}))
+}
+
+// Current returns the active tracer.
+func Current() *Tracer {
+	return current.Load()
+}
+
+// Replace installs t as the active tracer and returns the previous one.
+func Replace(t *Tracer) *Tracer {
+	mu.Lock()
+	defer mu.Unlock()
+	return current.Swap(t)
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span started by Start, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// StartServer starts the root span of an incoming request, continuing the
+// trace of remote when it is valid.
+func StartServer(ctx context.Context, name string, remote SpanContext) (context.Context, *Span) {
+	return start(ctx, name, KindServer, remote)
+}
+
+// Start starts a child of the span in ctx.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	var parent SpanContext
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.context
+	}
+	return start(ctx, name, kind, parent)
+}
+
+func start(ctx context.Context, name string, kind Kind, parent SpanContext) (context.Context, *Span) {
+	tracer := Current()
+	traceID := parent.TraceID
+	if !parent.IsValid() {
+		traceID = newTraceID()
+	}
+	span := &Span{
+		tracer: tracer,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		parent: parent.SpanID,
+		context: SpanContext{
+			TraceID:    traceID,
+			SpanID:     newSpanID(),
+			Sampled:    tracer.sample(parent, traceID),
+			TraceState: parent.TraceState,
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
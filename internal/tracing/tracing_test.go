@@ -0,0 +1,223 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gateway-go/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"upper case", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"short trace id", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := parseTraceparent(tt.value)
+			if ok != tt.valid {
+				t.Fatalf("valid = %v, 기대값 %v", ok, tt.valid)
+			}
+			if ok && sc.Sampled != tt.sampled {
+				t.Errorf("sampled = %v, 기대값 %v", sc.Sampled, tt.sampled)
+			}
+		})
+	}
+}
+
+func TestExtractAndInject(t *testing.T) {
+	in := http.Header{}
+	in.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	in.Add("tracestate", "vendor1=a")
+	in.Add("tracestate", "vendor2=b")
+
+	sc := Extract(in)
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("trace context 파싱 실패: %+v", sc)
+	}
+
+	out := http.Header{}
+	Inject(sc, out)
+	if got := out.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("traceparent = %q", got)
+	}
+	if got := out.Get("tracestate"); got != "vendor1=a,vendor2=b" {
+		t.Errorf("tracestate = %q", got)
+	}
+}
+
+func ratio(v float64) *float64 {
+	return &v
+}
+
+func TestSampling(t *testing.T) {
+	defer Replace(Replace(NewTracer(Config{Endpoint: "http://127.0.0.1:1", SampleRatio: ratio(0)})))
+
+	_, span := StartServer(context.Background(), "GET", SpanContext{})
+	if span.Context().Sampled {
+		t.Error("sample_ratio 0이면 새 trace는 샘플링되지 않아야 합니다")
+	}
+	if !span.Context().IsValid() {
+		t.Error("샘플링되지 않아도 trace context는 만들어져야 합니다")
+	}
+
+	parent := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	ctx, span := StartServer(context.Background(), "GET", parent)
+	if !span.Context().Sampled {
+		t.Error("샘플링된 부모를 따라야 합니다")
+	}
+	if span.Context().TraceID != parent.TraceID || span.Context().SpanID == parent.SpanID {
+		t.Errorf("부모 trace를 이어야 합니다: %+v", span.Context())
+	}
+	_, child := Start(ctx, "route", KindInternal)
+	if child.Context().TraceID != parent.TraceID || child.parent != span.Context().SpanID {
+		t.Errorf("child span의 부모가 잘못되었습니다: %+v", child.Context())
+	}
+
+	Replace(NewTracer(Config{Endpoint: "http://127.0.0.1:1", SampleRatio: ratio(1)}))
+	_, span = StartServer(context.Background(), "GET", SpanContext{})
+	if !span.Context().Sampled {
+		t.Error("sample_ratio 1이면 모든 trace가 샘플링되어야 합니다")
+	}
+
+	Replace(NewTracer(Config{SampleRatio: ratio(1)}))
+	_, span = StartServer(context.Background(), "GET", SpanContext{})
+	if span.Context().Sampled {
+		t.Error("endpoint가 없으면 새 trace를 샘플링하지 않아야 합니다")
+	}
+}
+
+func TestExporter(t *testing.T) {
+	received := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Token") != "secret" {
+			t.Errorf("잘못된 export 요청: %s %s", r.URL.Path, r.Header)
+		}
+		var body otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("OTLP JSON 디코딩 실패: %v", err)
+		}
+		received <- body
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer(Config{
+		Endpoint:      collector.URL + "/v1/traces",
+		ServiceName:   "edge",
+		Headers:       map[string]string{"X-Token": "secret"},
+		FlushInterval: time.Hour,
+	})
+	tracer.Start()
+	defer Replace(Replace(tracer))
+
+	ctx, root := StartServer(context.Background(), "GET", SpanContext{})
+	_, child := Start(ctx, "upstream", KindClient)
+	child.SetInt("http.response.status_code", 502)
+	child.SetError("502")
+	child.End()
+	root.SetString("http.route", "/api")
+	root.End()
+	// Close는 남은 span을 모두 보낸다
+	tracer.Close()
+
+	var body otlpRequest
+	select {
+	case body = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector가 span을 받지 못했습니다")
+	}
+
+	resource := body.ResourceSpans[0]
+	if name := *resource.Resource.Attributes[0].Value.StringValue; name != "edge" {
+		t.Errorf("service.name = %q", name)
+	}
+	spans := resource.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("span 수 = %d, 기대값 2", len(spans))
+	}
+	upstream, server := spans[0], spans[1]
+	if upstream.Name != "upstream" || upstream.Kind != KindClient || upstream.ParentSpanId != server.SpanId {
+		t.Errorf("upstream span이 잘못되었습니다: %+v", upstream)
+	}
+	if upstream.TraceId != server.TraceId || server.ParentSpanId != "" || server.Kind != KindServer {
+		t.Errorf("server span이 잘못되었습니다: %+v", server)
+	}
+	if upstream.Status.Code != statusCodeError || *upstream.Attributes[0].Value.IntValue != "502" {
+		t.Errorf("upstream span 상태/속성이 잘못되었습니다: %+v", upstream)
+	}
+	if server.Attributes[0].Key != "http.route" || server.StartTimeUnixNano == "" {
+		t.Errorf("server span 속성이 잘못되었습니다: %+v", server)
+	}
+}
+
+func TestExporterDropped(t *testing.T) {
+	records := &bytes.Buffer{}
+	logger.TestSetUpApp(records)
+	defer logger.TestSetUp()
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	exporter := NewExporter(collector.URL, "edge", nil, time.Hour)
+	// 시작 전이라 queue가 비워지지 않으므로 넘친 span은 버려진다
+	for i := 0; i < maxQueueSize+3; i++ {
+		exporter.enqueue(&Span{name: "span"})
+	}
+	if dropped := exporter.dropped.Load(); dropped != 3 {
+		t.Fatalf("버려진 span 수 = %d, 기대값 3", dropped)
+	}
+	exporter.Start()
+	exporter.Close()
+
+	// span마다가 아니라 한 번만 요약해서 남긴다
+	if count := strings.Count(records.String(), "Trace spans dropped"); count != 1 {
+		t.Errorf("drop 로그 수 = %d, 기대값 1: %s", count, records.String())
+	}
+	if !strings.Contains(records.String(), `"spans":3`) {
+		t.Errorf("drop 로그에 span 수가 없습니다: %s", records.String())
+	}
+	if exporter.dropped.Load() != 0 {
+		t.Error("로그 이후 drop 수가 초기화되지 않았습니다")
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	config, err := ReadConfig([]byte(`tracing:
+  endpoint: http://otel-collector:4318/v1/traces
+  service_name: edge
+  sample_ratio: 0.25
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Endpoint != "http://otel-collector:4318/v1/traces" || *config.SampleRatio != 0.25 || config.ServiceName != "edge" {
+		t.Errorf("설정을 잘못 읽었습니다: %+v", config)
+	}
+
+	for _, invalid := range []string{
+		"tracing:\n  sample_ratio: 1.5\n",
+		"tracing:\n  endpoint: otel-collector:4318\n",
+	} {
+		if _, err := ReadConfig([]byte(invalid)); err == nil {
+			t.Errorf("잘못된 설정이 통과했습니다: %q", invalid)
+		}
+	}
+}
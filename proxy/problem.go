@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeProblem = "application/problem+json"
+)
+
+// problem is an RFC 7807 problem details body. Reason is the machine
+// readable code, such as token_expired.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Reason   string `json:"reason"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// writeProblem writes the body as application/problem+json when the client
+// accepts it and as plain application/json otherwise.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, reason, detail string) {
+	contentType := contentTypeJSON
+	if acceptsProblem(r.Header.Values("Accept")) {
+		contentType = contentTypeProblem
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Reason:   reason,
+		Instance: r.URL.Path,
+	})
+}
+
+func acceptsProblem(accept []string) bool {
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == contentTypeProblem {
+				return true
+			}
+		}
+	}
+	return false
+}
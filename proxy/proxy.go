@@ -1,32 +1,113 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"gateway-go/internal/auth"
 	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/router"
+	"gateway-go/internal/tracing"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 type contextKey string
 
-const targetURLKey contextKey = "targetURL"
+const (
+	matchKey    contextKey = "match"
+	startKey    contextKey = "start"
+	exchangeKey contextKey = "exchange"
+)
 
 type Router interface {
-	Route(path string) (targetURL string, authType string, found bool)
+	Route(r *http.Request) (*router.Match, error)
 }
 
+// statusCatcherWriter records what was sent to the client for the access
+// log: the final status, the body size and the time to the first byte.
 type statusCatcherWriter struct {
 	http.ResponseWriter
 	status int
+	bytes  int64
+	start  time.Time
+	ttfb   time.Duration
 }
 
 func (s *statusCatcherWriter) WriteHeader(statusCode int) {
-	s.status = statusCode
+	s.markFirstByte()
+	// 1xx 응답 뒤에는 최종 응답이 이어진다
+	if statusCode >= http.StatusOK && s.status == 0 {
+		s.status = statusCode
+	}
 	s.ResponseWriter.WriteHeader(statusCode)
 }
 
+func (s *statusCatcherWriter) Write(b []byte) (int, error) {
+	s.markFirstByte()
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusCatcherWriter) finalStatus() int {
+	if s.status == 0 {
+		// 아무것도 쓰지 않은 응답은 net/http가 200으로 보낸다
+		return http.StatusOK
+	}
+	return s.status
+}
+
+func (s *statusCatcherWriter) markFirstByte() {
+	if s.ttfb == 0 {
+		s.ttfb = time.Since(s.start)
+	}
+}
+
+// Unwrap lets http.ResponseController reach Flush of the client connection,
+// which the reverse proxy needs for streamed responses.
+func (s *statusCatcherWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// countingBody counts the request body bytes read by the proxy. The count
+// is atomic since the transport may still be sending the body when the
+// response is complete.
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func countBody(r *http.Request) *countingBody {
+	body := &countingBody{ReadCloser: r.Body}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = body
+	}
+	return body
+}
+
+// exchange collects what happens upstream while a request is proxied.
+type exchange struct {
+	// 재시도를 포함해 upstream 응답 헤더를 받기까지 걸린 시간 (ns)
+	upstreamLatency atomic.Int64
+}
+
 type ProxyHandler struct {
 	Router Router
 	Proxy  httputil.ReverseProxy
@@ -39,46 +120,401 @@ func NewProxy(router Router) ProxyHandler {
 			Rewrite: func(req *httputil.ProxyRequest) {
 				routerDirector(req)
 			},
+			ModifyResponse: func(resp *http.Response) error {
+				// 게이트웨이가 정한 요청 ID를 upstream 응답이 덮어쓰지 않게 한다
+				resp.Header.Del(requestIdHeader)
+				observe(resp.Request.Context(), resp.StatusCode < http.StatusInternalServerError)
+				return nil
+			},
+			ErrorHandler: upstreamErrorHandler,
+			Transport:    routeTransport{},
 		},
 	}
 }
 
 func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	path, authTypeValue, ok := p.Router.Route(r.URL.Path)
-	if !ok {
-		http.NotFound(w, r)
-		logger.HTTP.LogTransaction(*r, http.StatusNotFound)
+	start := time.Now()
+	writer := &statusCatcherWriter{ResponseWriter: w, start: start}
+	body := countBody(r)
+	exchange := &exchange{}
+	// 요청 ID는 upstream 요청과 응답, 모든 로그에 같은 값으로 남긴다
+	requestId := requestIdOf(r)
+	r.Header.Set(requestIdHeader, requestId)
+	writer.Header().Set(requestIdHeader, requestId)
+	ctx, span := tracing.StartServer(logger.WithRequestId(r.Context(), requestId), r.Method, tracing.Extract(r.Header))
+	r = r.WithContext(ctx)
+	var match *router.Match
+	// r는 아래에서 context가 교체되므로 마지막 값으로 기록한다
+	defer func() {
+		endServerSpan(span, r, writer, match)
+		logTransaction(r, writer, match, body, exchange, start)
+		recordRequest(r, writer, match, start)
+	}()
+
+	_, routeSpan := tracing.Start(r.Context(), "route", tracing.KindInternal)
+	match, err := p.Router.Route(r)
+	if err != nil {
+		routeSpan.SetError(err.Error())
+	}
+	routeSpan.End()
+	if err != nil {
+		http.NotFound(writer, r)
+		return
+	}
+	defer match.Done()
+	inFlight := metrics.InFlight.With(match.Route)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	// ip, header key는 인증 전에 적용해 잘못된 자격 증명 시도도 제한한다
+	limiter := match.RateLimiter
+	if limiter != nil && !limiter.KeyedByUser() && !rateLimit(writer, r, match) {
 		return
 	}
-	authType := auth.ParseAuthType(authTypeValue)
-	if authType != auth.NONE {
-		proxy := auth.Get(string(authType))
-		err := proxy.Handle(r)
-		if err != nil {
-			w.WriteHeader(401)
+
+	if !authorize(writer, r, match) {
+		return
+	}
+
+	if limiter != nil && limiter.KeyedByUser() && !rateLimit(writer, r, match) {
+		return
+	}
+
+	// target은 인증과 rate limit을 통과한 요청에만 고른다. 거부될 요청이
+	// half-open probe slot을 잡고 있거나 upstream 상태를 알려주지 않게 한다
+	if err := match.Pick(); err != nil {
+		http.Error(writer, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	if match.Retry != nil && match.Retry.AllowsMethod(r.Method) {
+		if err := bufferBody(r, match.Retry.MaxBodyBytes); err != nil {
+			http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 	}
 
-	ctx := context.WithValue(r.Context(), targetURLKey, path)
+	ctx = context.WithValue(r.Context(), matchKey, match)
+	ctx = context.WithValue(ctx, startKey, time.Now())
+	ctx = context.WithValue(ctx, exchangeKey, exchange)
+	if match.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, match.Timeout)
+		defer cancel()
+	}
 	r = r.WithContext(ctx)
-	writer := statusCatcherWriter{
-		ResponseWriter: w,
-		status:         -1,
+	p.Proxy.ServeHTTP(writer, r)
+}
+
+// rateLimit applies the route's rate limit. It writes the 429 response and
+// returns false when the request is over the limit.
+func rateLimit(w http.ResponseWriter, r *http.Request, match *router.Match) bool {
+	result := match.RateLimiter.Allow(r)
+	result.SetHeaders(w.Header())
+	if !result.Allowed {
+		metrics.RateLimited.With(match.Route).Inc()
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// authorize authenticates r and applies the route's policy. It writes the
+// error response and returns false when the request may not pass.
+func authorize(w http.ResponseWriter, r *http.Request, match *router.Match) bool {
+	if match.Auth.IsEmpty() && match.Policy == nil {
+		return true
+	}
+	_, span := tracing.Start(r.Context(), "auth", tracing.KindInternal)
+	defer span.End()
+	span.SetString("gateway.auth", match.Auth.String())
+
+	if !match.Auth.IsEmpty() {
+		if err := match.Authenticate(r); err != nil {
+			// 인증 서버 장애는 클라이언트가 토큰을 버리지 않도록 503으로 알린다
+			status := http.StatusServiceUnavailable
+			if !auth.Unavailable(err) {
+				status = http.StatusUnauthorized
+				for _, challenge := range match.Challenges(err) {
+					w.Header().Add("WWW-Authenticate", challenge)
+				}
+			}
+			span.SetError(auth.ReasonOf(err))
+			logger.Audit.LogFailure(r, match.Route, "", auth.ReasonOf(err))
+			metrics.AuthFailures.With(auth.ProviderOf(err), auth.ReasonOf(err)).Inc()
+			writeProblem(w, r, status, auth.ReasonOf(err), auth.MessageOf(err))
+			return false
+		}
+	}
+
+	identity, authenticated := auth.IdentityFrom(r)
+	if match.Policy != nil && !match.Policy.Allows(r.Method, identity) {
+		span.SetError(auth.ReasonForbidden)
+		logger.Audit.LogFailure(r, match.Route, identity.UserId, auth.ReasonForbidden)
+		metrics.AuthFailures.With("policy", auth.ReasonForbidden).Inc()
+		writeProblem(w, r, http.StatusForbidden, auth.ReasonForbidden, "access to this route is not allowed")
+		return false
+	}
+	if authenticated {
+		logger.Audit.LogSuccess(r, match.Route, identity.UserId)
+	}
+	return true
+}
+
+func endServerSpan(span *tracing.Span, r *http.Request, writer *statusCatcherWriter, match *router.Match) {
+	status := writer.finalStatus()
+	span.SetString("http.request.method", r.Method)
+	span.SetString("url.path", r.URL.Path)
+	span.SetInt("http.response.status_code", int64(status))
+	if match != nil {
+		span.SetString("http.route", match.Route)
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetError(http.StatusText(status))
+	}
+	span.End()
+}
+
+func logTransaction(r *http.Request, writer *statusCatcherWriter, match *router.Match, body *countingBody, exchange *exchange, start time.Time) {
+	t := logger.Transaction{
+		Request:         r,
+		Status:          writer.finalStatus(),
+		BytesIn:         body.n.Load(),
+		BytesOut:        writer.bytes,
+		Latency:         time.Since(start),
+		UpstreamLatency: time.Duration(exchange.upstreamLatency.Load()),
+		TTFB:            writer.ttfb,
+	}
+	if span := tracing.SpanFromContext(r.Context()); span != nil {
+		t.TraceId = span.Context().TraceID.String()
+	}
+	if match != nil {
+		t.Route = match.Route
+		t.Upstream = upstreamOf(match)
 	}
-	p.Proxy.ServeHTTP(&writer, r)
-	logger.HTTP.LogTransaction(*r, writer.status)
+	if identity, ok := auth.IdentityFrom(r); ok {
+		t.UserId = identity.UserId
+	}
+	logger.HTTP.LogTransaction(t)
+}
+
+func recordRequest(r *http.Request, writer *statusCatcherWriter, match *router.Match, start time.Time) {
+	route := metrics.UnmatchedRoute
+	if match != nil {
+		route = match.Route
+	}
+	class := metrics.StatusClass(writer.finalStatus())
+	method := metrics.Method(r.Method)
+	metrics.Requests.With(route, method, class).Inc()
+	metrics.RequestDuration.With(route, method, class).Observe(time.Since(start).Seconds())
+}
+
+func upstreamOf(match *router.Match) string {
+	if match.Target != nil {
+		return match.Target.URL
+	}
+	if match.URL == "" {
+		return ""
+	}
+	if u, err := url.Parse(match.URL); err == nil {
+		return u.Scheme + "://" + u.Host
+	}
+	return ""
 }
 
 func routerDirector(req *httputil.ProxyRequest) {
-	path := req.In.Context().Value(targetURLKey).(string)
-	targetURL, err := url.Parse(path)
+	match := req.In.Context().Value(matchKey).(*router.Match)
+	targetURL, err := url.Parse(match.URL)
 	if err != nil {
-		logger.App.Error("url parse miss", "err", err)
+		logger.App.ErrorContext(req.In.Context(), "url parse miss", "err", err)
 		return
 	}
 
+	// 클라이언트 query string은 target(또는 rewrite) query 뒤에 그대로 붙인다
+	targetURL.RawQuery = router.JoinQuery(targetURL.RawQuery, req.In.URL.RawQuery)
 	req.Out.Host = targetURL.Host
 	req.Out.URL = targetURL
 	req.SetXForwarded()
+	// upstream span이 만들어지면 routeTransport에서 그 span으로 다시 덮어쓴다
+	if span := tracing.SpanFromContext(req.In.Context()); span != nil {
+		tracing.Inject(span.Context(), req.Out.Header)
+	}
+}
+
+func upstreamErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	kind := "error"
+	switch {
+	case errors.Is(err, context.Canceled):
+		// 클라이언트가 요청을 취소한 경우는 upstream 장애로 보지 않는다
+		kind = "canceled"
+		abandon(r.Context())
+	case isTimeout(err):
+		kind = "timeout"
+		observe(r.Context(), false)
+	default:
+		observe(r.Context(), false)
+	}
+	if match, ok := r.Context().Value(matchKey).(*router.Match); ok {
+		metrics.UpstreamErrors.With(match.Route, kind).Inc()
+	}
+	logger.App.WarnContext(r.Context(), "upstream request failed", "path", r.URL.Path, "error", err)
+	if kind == "timeout" {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// routeTransport sends each request through the RoundTripper of the route
+// it matched, retrying according to the route's retry policy.
+type routeTransport struct{}
+
+func (routeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if exchange, ok := req.Context().Value(exchangeKey).(*exchange); ok {
+		start := time.Now()
+		defer func() {
+			exchange.upstreamLatency.Add(int64(time.Since(start)))
+		}()
+	}
+	match, _ := req.Context().Value(matchKey).(*router.Match)
+	var transport http.RoundTripper = http.DefaultTransport
+	if match != nil && match.Transport != nil {
+		transport = match.Transport
+	}
+	if match == nil || match.Retry == nil || !canRetry(req, match.Retry) {
+		return tracedRoundTrip(transport, req, 1)
+	}
+
+	policy := match.Retry
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := tracedRoundTrip(transport, attemptReq, attempt)
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		logger.HTTP.LogAttempt(req, attempt, status, err)
+
+		if attempt >= policy.MaxAttempts || !policy.Retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(policy.BackoffFor(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tracedRoundTrip sends one attempt in its own client span, which becomes
+// the parent of the upstream's spans.
+func tracedRoundTrip(transport http.RoundTripper, req *http.Request, attempt int) (*http.Response, error) {
+	_, span := tracing.Start(req.Context(), "upstream", tracing.KindClient)
+	defer span.End()
+	// RoundTripper는 받은 요청을 수정하면 안 되므로 헤더만 복사해서 보낸다
+	out := *req
+	out.Header = req.Header.Clone()
+	tracing.Inject(span.Context(), out.Header)
+	req = &out
+	span.SetString("http.request.method", req.Method)
+	span.SetString("server.address", req.URL.Host)
+	span.SetString("url.full", req.URL.Redacted())
+	if attempt > 1 {
+		span.SetInt("http.request.resend_count", int64(attempt-1))
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		span.SetError(err.Error())
+		return nil, err
+	}
+	span.SetInt("http.response.status_code", int64(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetError(strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// canRetry reports whether req can be sent again: its method must be allowed
+// and its body, if any, must have been buffered by bufferBody.
+func canRetry(req *http.Request, policy *router.RetryPolicy) bool {
+	if !policy.AllowsMethod(req.Method) {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// bufferBody reads up to limit bytes of the request body into memory so it
+// can be replayed on retry. Larger bodies are streamed through unchanged
+// and the request will not be retried.
+func bufferBody(r *http.Request, limit int64) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(buf)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return nil
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(buf))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+	return nil
+}
+
+// observe feeds the outcome of an upstream request into passive health
+// checking and the target's circuit breaker.
+func observe(ctx context.Context, success bool) {
+	match, ok := ctx.Value(matchKey).(*router.Match)
+	if !ok || match.Target == nil {
+		return
+	}
+	var latency time.Duration
+	if start, ok := ctx.Value(startKey).(time.Time); ok {
+		latency = time.Since(start)
+	}
+	if match.Observe(success, latency) {
+		logger.App.WarnContext(ctx, "Upstream target ejected", "target", match.Target.URL)
+	}
+}
+
+func abandon(ctx context.Context) {
+	if match, ok := ctx.Value(matchKey).(*router.Match); ok {
+		match.Abandon()
+	}
 }
@@ -1,27 +1,48 @@
 package proxy_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"gateway-go/internal/auth"
 	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
 	"gateway-go/internal/router"
+	"gateway-go/internal/tracing"
 	"gateway-go/proxy"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type MockRouter struct {
 	Routes map[string]string
 }
 
-func (m *MockRouter) Route(path string) (string, string, bool) {
+func (m *MockRouter) Route(r *http.Request) (*router.Match, error) {
 	// 실제 게이트웨이에서는 복잡한 로직이 있겠지만, 테스트를 위해 단순 매핑합니다.
-	if target, ok := m.Routes[path]; ok {
-		return target, "", true
+	if target, ok := m.Routes[r.URL.Path]; ok {
+		return &router.Match{URL: target}, nil
 	}
-	return "", "", false
+	return nil, router.ErrNotFound
+}
+
+type StubAuthProxy struct{}
+
+func (StubAuthProxy) Handle(*http.Request) error {
+	return nil
+}
+
+func (StubAuthProxy) GetType() auth.ProxyType {
+	return auth.ProxyType(auth.JWT)
 }
 
 func TestMain(m *testing.M) {
@@ -97,6 +118,42 @@ func TestProxyHandlerIntegration(t *testing.T) {
 	})
 }
 
+func TestAuthRouteRequiresToken(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	err := auth.SetUpAuth([]byte(`auth:
+  jwt-auth:
+    secret: asdfabsadfasfdfassadfdsafdasfdsafdasfdasfadsfdasfdasf
+    auth-header: Authorization
+    claims:
+      user-id: "userid"
+      role: "role"
+`))
+	if err != nil {
+		t.Fatal("auth setup fail ", err)
+	}
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf("routes:\n  - prefix: /secure\n    target: %s\n    auth: jwt\n", backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/secure/data")
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	resp.Body.Close()
+	// auth: jwt 라우트는 토큰 없이 upstream에 도달하면 안 된다
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("상태 코드 불일치. 기대값: 401, 실제값: %d", resp.StatusCode)
+	}
+}
+
 func TestProxyHandlerIntegrationWithNotMockRouter(t *testing.T) {
 	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -114,9 +171,13 @@ func TestProxyHandlerIntegrationWithNotMockRouter(t *testing.T) {
     target: %s
     auth: jwt
 `,
-		targeturl)
+		targeturl, targeturl)
 
-	newRouter, _ := router.NewRouter([]byte(yamlStr))
+	auth.Save(StubAuthProxy{})
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
 	proxyHandler := proxy.NewProxy(newRouter)
 
 	gateway := httptest.NewServer(&proxyHandler)
@@ -180,3 +241,1168 @@ func TestProxyHandlerIntegrationWithNotMockRouter(t *testing.T) {
 		}
 	})
 }
+
+func TestPassiveEjection(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    health_check:
+      passive:
+        max_failures: 2
+        eject_duration: 1m
+`, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	expected := []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusServiceUnavailable}
+	for i, status := range expected {
+		resp, err := http.Get(gateway.URL + "/api")
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%d번째 요청 상태 코드 불일치. 기대값: %d, 실제값: %d", i+1, status, resp.StatusCode)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    rate_limit:
+      requests_per_second: 0.1
+      burst: 1
+`, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/api")
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Errorf("첫 요청 처리 실패: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	resp, err = http.Get(gateway.URL + "/api")
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("상태 코드 불일치. 기대값: 429, 실제값: %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") != "10" {
+		t.Errorf("Retry-After 불일치: %s", resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestRateLimitBeforeAuth(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    auth: jwt
+    rate_limit:
+      requests_per_second: 0.1
+      burst: 1
+`, backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	// 잘못된 자격 증명을 반복해서 보내는 클라이언트도 제한한다
+	expected := []int{http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range expected {
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api", nil)
+		req.Header.Set("Authorization", "Bearer bogus")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%d번째 요청 상태 코드 불일치. 기대값: %d, 실제값: %d", i+1, status, resp.StatusCode)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    circuit_breaker:
+      error_rate: 0.5
+      min_requests: 2
+      open_duration: 1m
+`, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(gateway.URL + "/api")
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("open 상태에서 upstream 호출됨: %d", calls.Load())
+	}
+	upstreams := newRouter.Upstreams()
+	if upstreams[0].Circuit != "open" {
+		t.Errorf("circuit 상태 불일치: %s", upstreams[0].Circuit)
+	}
+}
+
+func TestCircuitBreakerAfterAuth(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	var failing atomic.Bool
+	failing.Store(true)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    auth: jwt
+    circuit_breaker:
+      min_requests: 1
+      open_duration: 50ms
+`, backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	send := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	token := signRoles(t, "USER")
+	if status := send(token); status != http.StatusBadGateway {
+		t.Fatalf("상태 코드 불일치. 기대값: 502, 실제값: %d", status)
+	}
+	// 인증하지 않은 클라이언트에게 upstream 상태(503)를 알려주지 않는다
+	if status := send(""); status != http.StatusUnauthorized {
+		t.Errorf("open 상태에서 인증 전 상태 코드 불일치. 기대값: 401, 실제값: %d", status)
+	}
+	if status := send(token); status != http.StatusServiceUnavailable {
+		t.Errorf("open 상태 상태 코드 불일치. 기대값: 503, 실제값: %d", status)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	// 인증에 실패한 요청은 half-open probe slot을 차지하지 않는다
+	if status := send("bogus"); status != http.StatusUnauthorized {
+		t.Errorf("잘못된 토큰 상태 코드 불일치. 기대값: 401, 실제값: %d", status)
+	}
+	if circuit := newRouter.Upstreams()[0].Circuit; circuit != "half-open" {
+		t.Errorf("인증 실패 요청이 probe로 쓰임: %s", circuit)
+	}
+	if status := send(token); status != http.StatusOK {
+		t.Errorf("probe 요청 상태 코드 불일치. 기대값: 200, 실제값: %d", status)
+	}
+}
+
+func TestUpstreamTimeout(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /header
+    target: %s
+    timeouts:
+      response_header: 50ms
+  - prefix: /deadline
+    target: %s
+    timeouts:
+      request: 50ms
+    transport:
+      max_idle_conns_per_host: 4
+      http2: false
+`, backend.URL, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	defer newRouter.Close()
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	for _, path := range []string{"/header", "/deadline"} {
+		resp, err := http.Get(gateway.URL + path)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusGatewayTimeout {
+			t.Errorf("%s 상태 코드 불일치. 기대값: 504, 실제값: %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    retry:
+      max_attempts: 3
+      backoff: 1ms
+      max_backoff: 5ms
+`, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	t.Run("idempotent request is retried with body", func(t *testing.T) {
+		calls.Store(0)
+		req, _ := http.NewRequest(http.MethodPut, gateway.URL+"/api", strings.NewReader("payload"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+			t.Errorf("재시도 실패: %d %q", resp.StatusCode, body)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("시도 횟수 불일치: %d", calls.Load())
+		}
+	})
+
+	t.Run("non idempotent request is not retried", func(t *testing.T) {
+		calls.Store(0)
+		resp, err := http.Post(gateway.URL+"/api", "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+			t.Errorf("POST 재시도됨: %d calls=%d", resp.StatusCode, calls.Load())
+		}
+	})
+}
+
+func TestPreserveQueryAndRawPath(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s?%s", r.URL.EscapedPath(), r.URL.RawQuery)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s/base?tenant=a
+  - prefix: /v1
+    target: %s
+    rewrite:
+      - match: ^/v1/users/([^/]+)$
+        replace: /users?id=$1
+`, backend.URL, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"encoded slash", "/api/files/a%2Fb.txt", "/base/files/a%2Fb.txt?tenant=a"},
+		{"repeated query keys", "/api/search?tag=x&tag=y&q=a+b", "/base/search?tenant=a&tag=x&tag=y&q=a+b"},
+		{"trailing slash", "/api/dir/?page=1", "/base/dir/?tenant=a&page=1"},
+		{"rewrite query merged", "/v1/users/42?fields=name", "/users?id=42&fields=name"},
+		// path에서 캡처한 값이 query 파라미터를 추가하거나 공백으로 바뀌면 안 된다
+		{"rewrite capture escaped", "/v1/users/bob&role=admin", "/users?id=bob%26role%3Dadmin"},
+		{"rewrite capture plus", "/v1/users/a+b%20c", "/users?id=a%2Bb+c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(gateway.URL + tt.path)
+			if err != nil {
+				t.Fatalf("프록시 요청 실패: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.expected {
+				t.Errorf("전달된 URL 불일치. 기대값: %s, 실제값: %s", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestEncodedPathCannotSkipAuthRoute(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	var forwarded atomic.Value
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded.Store(r.URL.EscapedPath())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s/api
+  - prefix: /api/admin
+    target: %s/api/admin
+    auth: jwt
+    allow_roles: [ADMIN]
+`, backend.URL, backend.URL)
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	// 인코딩된 경로도 디코딩한 경로 기준으로 /api/admin 라우트에 매칭되어야 한다
+	for _, path := range []string{"/api/%61dmin/users", "/api/admin%2Fusers"} {
+		forwarded.Store("")
+		resp, err := http.Get(gateway.URL + path)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: 상태 코드 불일치. 기대값: 401, 실제값: %d", path, resp.StatusCode)
+		}
+		if got := forwarded.Load(); got != "" {
+			t.Errorf("%s: 인증 없이 upstream에 전달되었습니다: %v", path, got)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+path, nil)
+		req.Header.Set("Authorization", signRoles(t, "ADMIN"))
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: 상태 코드 불일치. 기대값: 200, 실제값: %d", path, resp.StatusCode)
+		}
+		// 매칭된 prefix로 시작하지 않는 인코딩은 디코딩된 경로로 보낸다
+		if got := forwarded.Load(); got != "/api/admin/users" {
+			t.Errorf("%s: 전달된 경로 = %v, 기대값 /api/admin/users", path, got)
+		}
+	}
+}
+
+const roleSecret = "testsecrettestsecrettestsecrettestsecret"
+
+func signRoles(t *testing.T, roles ...string) string {
+	claims := jwt.MapClaims{"userId": "tester", "role": roles}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(roleSecret))
+	if err != nil {
+		t.Fatal("token create fail ", err)
+	}
+	return signed
+}
+
+func TestRoleAuthorization(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api/users
+    target: %s
+    auth: jwt
+    allow_roles: [USER, ADMIN]
+    rules:
+      - methods: [DELETE]
+        allow_roles: [ADMIN]
+`, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		token    string
+		expected int
+	}{
+		{"unauthenticated", http.MethodGet, "", http.StatusUnauthorized},
+		{"user reads", http.MethodGet, signRoles(t, "USER"), http.StatusOK},
+		{"guest forbidden", http.MethodGet, signRoles(t, "GUEST"), http.StatusForbidden},
+		{"user cannot delete", http.MethodDelete, signRoles(t, "USER"), http.StatusForbidden},
+		{"admin deletes", http.MethodDelete, signRoles(t, "ADMIN"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, gateway.URL+"/api/users/1", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("프록시 요청 실패: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expected {
+				t.Errorf("상태 코드 불일치. 기대값: %d, 실제값: %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestIntrospectionOutage(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer endpoint.Close()
+
+	store, err := auth.LoadAuth([]byte(`auth:
+  introspect-auth:
+    endpoint: ` + endpoint.URL + `
+    client-id: gateway
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	newRouter, err := router.NewRouter([]byte("routes:\n  - prefix: /api\n    target: http://127.0.0.1:1\n    auth: introspect\n"))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api", nil)
+	req.Header.Set("Authorization", "Bearer opaque-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	resp.Body.Close()
+	// 인증 서버 장애는 토큰 거부(401)가 아니다
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("상태 코드 불일치. 기대값: 503, 실제값: %d", resp.StatusCode)
+	}
+	if challenge := resp.Header.Get("WWW-Authenticate"); challenge != "" {
+		t.Errorf("장애 응답에 challenge가 있습니다: %s", challenge)
+	}
+}
+
+func TestStripTrustedHeaders(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    user-id-header: X-Auth-User
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s|%s", r.Header.Get("X-User-Id"), r.Header.Get("X-Auth-User"),
+			r.Header.Get("X-User-Role"), r.Header.Get("X-Tenant-Id"))
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`trusted_headers: [X-Tenant-Id]
+routes:
+  - prefix: /public
+    target: %s
+  - prefix: /private
+    target: %s
+    auth: jwt
+`, backend.URL, backend.URL)
+
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		expected string
+	}{
+		{"no auth route", "/public", "", "|||"},
+		{"auth route", "/private", signRoles(t, "USER"), "|tester|USER|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, gateway.URL+tt.path, nil)
+			req.Header.Set("X-User-Id", "admin")
+			req.Header.Set("X-Auth-User", "admin")
+			req.Header.Set("X-User-Role", "ADMIN")
+			req.Header.Set("X-Tenant-Id", "other")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("프록시 요청 실패: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.expected {
+				t.Errorf("upstream 헤더 불일치. 기대값: %q, 실제값: %q", tt.expected, string(body))
+			}
+		})
+	}
+}
+
+func TestSpoofedIdentityCannotSelectRoute(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-User-Role"))
+	}))
+	defer backend.Close()
+
+	// 클라이언트가 위조할 수 있는 헤더로 라우트를 고르는 설정은 거부한다
+	_, err := router.NewRouter([]byte(fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    headers:
+      X-User-Role: ADMIN
+`, backend.URL)))
+	if err == nil {
+		t.Fatal("신뢰 헤더로 라우트를 고르는 설정 허용")
+	}
+
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+`, backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api", nil)
+	req.Header.Set("X-User-Role", "ADMIN")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "" {
+		t.Errorf("위조한 사용자 헤더가 upstream에 전달됨: %q", body)
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAuthFailureResponse(t *testing.T) {
+	store, err := auth.LoadAuth([]byte(`auth:
+  jwt-auth:
+    secret: ` + roleSecret + `
+    auth-header: Authorization
+    claims:
+      user-id: userId
+      role: role
+`))
+	if err != nil {
+		t.Fatal("auth create fail ", err)
+	}
+	previous := auth.Replace(store)
+	defer auth.Replace(previous)
+
+	audit := &syncBuffer{}
+	logger.TestSetUpAudit(audit)
+	defer logger.TestSetUp()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /api
+    target: %s
+    auth: jwt
+    allow_roles: [ADMIN]
+`, backend.URL)
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": "tester", "role": []string{"ADMIN"}, "exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	expiredToken, _ := expired.SignedString([]byte(roleSecret))
+
+	tests := []struct {
+		name        string
+		token       string
+		accept      string
+		status      int
+		reason      string
+		contentType string
+		challenge   string
+	}{
+		{"missing token", "", "", http.StatusUnauthorized, "missing_credentials", "application/json", `Bearer realm="gateway"`},
+		{"expired token", expiredToken, "application/problem+json", http.StatusUnauthorized, "token_expired", "application/problem+json",
+			`Bearer realm="gateway", error="invalid_token", error_description="token is expired"`},
+		{"forbidden role", signRoles(t, "USER"), "", http.StatusForbidden, "forbidden", "application/json", ""},
+		{"allowed", signRoles(t, "ADMIN"), "", http.StatusOK, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/api/users", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("프록시 요청 실패: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("상태 코드 불일치. 기대값: %d, 실제값: %d", tt.status, resp.StatusCode)
+			}
+			if resp.Header.Get("WWW-Authenticate") != tt.challenge {
+				t.Errorf("WWW-Authenticate 불일치. 기대값: %q, 실제값: %q", tt.challenge, resp.Header.Get("WWW-Authenticate"))
+			}
+			if tt.reason == "" {
+				return
+			}
+			if resp.Header.Get("Content-Type") != tt.contentType {
+				t.Errorf("Content-Type 불일치. 기대값: %s, 실제값: %s", tt.contentType, resp.Header.Get("Content-Type"))
+			}
+			var body struct {
+				Status int    `json:"status"`
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal("응답 body 파싱 실패 ", err)
+			}
+			if body.Status != tt.status || body.Reason != tt.reason {
+				t.Errorf("응답 body 불일치: %+v", body)
+			}
+		})
+	}
+
+	records := audit.String()
+	for _, expected := range []string{`"reason":"missing_credentials"`, `"reason":"token_expired"`,
+		`"reason":"forbidden"`, `"msg":"Auth succeeded"`, `"route":"/api"`, `"client_ip":"127.0.0.1"`} {
+		if !strings.Contains(records, expected) {
+			t.Errorf("audit log에 %s가 없습니다:\n%s", expected, records)
+		}
+	}
+	if strings.Contains(records, expiredToken) {
+		t.Error("audit log에 토큰이 기록되었습니다")
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Default.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{`gateway_auth_failures_total{provider="jwt",reason="token_expired"}`,
+		`gateway_auth_failures_total{provider="policy",reason="forbidden"}`} {
+		if !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("metrics 출력에 %s가 없습니다", expected)
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	records := &syncBuffer{}
+	logger.TestSetUpHTTP(records)
+	defer logger.TestSetUp()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	defer backend.Close()
+
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf("routes:\n  - prefix: /api\n    target: %s\n", backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	resp, err := http.Post(gateway.URL+"/api/orders?page=2", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	var entry struct {
+		Status          int     `json:"status"`
+		Path            string  `json:"path"`
+		Query           string  `json:"query"`
+		ClientIP        string  `json:"client_ip"`
+		Route           string  `json:"route"`
+		Upstream        string  `json:"upstream"`
+		Proto           string  `json:"proto"`
+		BytesIn         int64   `json:"bytes_in"`
+		BytesOut        int64   `json:"bytes_out"`
+		Latency         float64 `json:"latency"`
+		UpstreamLatency float64 `json:"upstream_latency"`
+		TTFB            float64 `json:"ttfb"`
+	}
+	if err := json.Unmarshal([]byte(records.String()), &entry); err != nil {
+		t.Fatalf("access log 파싱 실패: %v\n%s", err, records.String())
+	}
+
+	if entry.Status != http.StatusCreated || entry.Path != "/api/orders" || entry.Query != "page=2" {
+		t.Errorf("요청 정보 불일치: %+v", entry)
+	}
+	if entry.ClientIP != "127.0.0.1" || entry.Route != "/api" || entry.Upstream != backend.URL || entry.Proto != "HTTP/1.1" {
+		t.Errorf("연결 정보 불일치: %+v", entry)
+	}
+	if entry.BytesIn != 5 || entry.BytesOut != 7 {
+		t.Errorf("byte 수 불일치: in=%d out=%d", entry.BytesIn, entry.BytesOut)
+	}
+	tenMillis := float64(10 * time.Millisecond)
+	if entry.UpstreamLatency < tenMillis || entry.TTFB < entry.UpstreamLatency || entry.Latency < entry.TTFB {
+		t.Errorf("시간 측정 불일치: latency=%v upstream=%v ttfb=%v", entry.Latency, entry.UpstreamLatency, entry.TTFB)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /metrics-test
+    target: %s
+    rate_limit:
+      requests_per_second: 0.1
+      burst: 1
+  - prefix: /metrics-down
+    target: http://127.0.0.1:1
+`, backend.URL)
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	for _, path := range []string{"/metrics-test", "/metrics-test", "/metrics-down", "/metrics-unknown"} {
+		resp, err := http.Get(gateway.URL + path)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+	}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(fmt.Sprintf("JUNK%d", i), gateway.URL+"/metrics-unknown", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Default.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	expected := []string{
+		`gateway_requests_total{route="/metrics-test",method="GET",status_class="2xx"} 1`,
+		`gateway_requests_total{route="/metrics-test",method="GET",status_class="4xx"} 1`,
+		`gateway_rate_limited_total{route="/metrics-test"} 1`,
+		`gateway_upstream_errors_total{route="/metrics-down",kind="error"} 1`,
+		`gateway_requests_total{route="/metrics-down",method="GET",status_class="5xx"} 1`,
+		`gateway_request_duration_seconds_count{route="/metrics-test",method="GET",status_class="2xx"} 1`,
+		`gateway_requests_in_flight{route="/metrics-test"} 0`,
+		`route="unmatched",method="GET",status_class="4xx"}`,
+		`gateway_requests_total{route="unmatched",method="OTHER",status_class="4xx"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics 출력에 %q가 없습니다", line)
+		}
+	}
+	// 표준이 아닌 method는 label 값으로 쓰지 않는다
+	if strings.Contains(body, "JUNK") {
+		t.Error("임의의 method가 metrics label에 기록되었습니다")
+	}
+}
+
+func TestTracing(t *testing.T) {
+	var upstreamTraceparent atomic.Value
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent.Store(r.Header.Get("traceparent") + " " + r.Header.Get("tracestate"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	type exportedSpan struct {
+		TraceId      string `json:"traceId"`
+		SpanId       string `json:"spanId"`
+		ParentSpanId string `json:"parentSpanId"`
+		Name         string `json:"name"`
+	}
+	var mu sync.Mutex
+	var spans []exportedSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("OTLP JSON 디코딩 실패: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resource := range body.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				spans = append(spans, scope.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	tracingConfig, err := tracing.ReadConfig([]byte(fmt.Sprintf(`tracing:
+  endpoint: %s/v1/traces
+  sample_ratio: 0
+`, collector.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := tracing.NewTracer(tracingConfig)
+	tracer.Start()
+	defer tracing.Replace(tracing.Replace(tracer))
+
+	yamlStr := fmt.Sprintf(`routes:
+  - prefix: /traced
+    target: %s
+`, backend.URL)
+	newRouter, err := router.NewRouter([]byte(yamlStr))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const clientSpanId = "00f067aa0ba902b7"
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/traced", nil)
+	req.Header.Set("traceparent", "00-"+traceId+"-"+clientSpanId+"-01")
+	req.Header.Set("tracestate", "vendor=a")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("프록시 요청 실패: %v", err)
+	}
+	resp.Body.Close()
+	// sample_ratio가 0이어도 샘플링된 부모는 따른다
+	tracer.Close()
+
+	propagated, _ := upstreamTraceparent.Load().(string)
+	if !strings.HasPrefix(propagated, "00-"+traceId+"-") || !strings.HasSuffix(propagated, "-01 vendor=a") {
+		t.Fatalf("upstream에 전달된 trace context가 잘못되었습니다: %q", propagated)
+	}
+	if strings.Contains(propagated, clientSpanId) {
+		t.Errorf("upstream의 부모는 게이트웨이 span이어야 합니다: %q", propagated)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	byName := map[string]exportedSpan{}
+	for _, span := range spans {
+		if span.TraceId != traceId {
+			t.Errorf("다른 trace의 span입니다: %+v", span)
+		}
+		byName[span.Name] = span
+	}
+	server, route, upstream := byName["GET"], byName["route"], byName["upstream"]
+	if server.ParentSpanId != clientSpanId {
+		t.Errorf("server span의 부모 = %q, 기대값 %q", server.ParentSpanId, clientSpanId)
+	}
+	if route.ParentSpanId != server.SpanId || upstream.ParentSpanId != server.SpanId {
+		t.Errorf("route/upstream span은 server span의 자식이어야 합니다: %+v", spans)
+	}
+	if !strings.Contains(propagated, "-"+upstream.SpanId+"-") {
+		t.Errorf("upstream에는 upstream span id가 전달되어야 합니다: %q, %q", propagated, upstream.SpanId)
+	}
+}
+
+func TestRequestId(t *testing.T) {
+	httpRecords := &syncBuffer{}
+	appRecords := &syncBuffer{}
+	logger.TestSetUpHTTP(httpRecords)
+	logger.TestSetUpApp(appRecords)
+	defer logger.TestSetUp()
+
+	var upstreamId atomic.Value
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamId.Store(r.Header.Get("X-Request-Id"))
+		w.Header().Set("X-Request-Id", "upstream-id")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	newRouter, err := router.NewRouter([]byte(fmt.Sprintf(`routes:
+  - prefix: /ids
+    target: %s
+  - prefix: /ids-down
+    target: http://127.0.0.1:1
+`, backend.URL)))
+	if err != nil {
+		t.Fatal("router create fail ", err)
+	}
+	proxyHandler := proxy.NewProxy(newRouter)
+	gateway := httptest.NewServer(&proxyHandler)
+	defer gateway.Close()
+
+	send := func(path, requestId string) string {
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+path, nil)
+		if requestId != "" {
+			req.Header.Set("X-Request-Id", requestId)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("프록시 요청 실패: %v", err)
+		}
+		resp.Body.Close()
+		if values := resp.Header.Values("X-Request-Id"); len(values) != 1 {
+			t.Fatalf("응답의 X-Request-Id = %v, 하나여야 합니다", values)
+		}
+		return resp.Header.Get("X-Request-Id")
+	}
+
+	// 유효한 요청 ID는 그대로 전달된다
+	if got := send("/ids/kept", "client-id.42"); got != "client-id.42" {
+		t.Errorf("응답 요청 ID = %q, 기대값 client-id.42", got)
+	}
+	if got := upstreamId.Load(); got != "client-id.42" {
+		t.Errorf("upstream 요청 ID = %v, 기대값 client-id.42", got)
+	}
+
+	// 허용되지 않는 문자나 너무 긴 값은 새로 만든다
+	for _, invalid := range []string{"", "bad id<script>", strings.Repeat("a", 129)} {
+		got := send("/ids/generated", invalid)
+		if len(got) != 36 || got == invalid {
+			t.Errorf("요청 ID %q 대신 uuid가 만들어져야 합니다: %q", invalid, got)
+		}
+		if upstream := upstreamId.Load(); upstream != got {
+			t.Errorf("upstream 요청 ID = %v, 응답 = %q", upstream, got)
+		}
+	}
+
+	if send("/ids-down", "failing-request") != "failing-request" {
+		t.Error("upstream 오류 응답에도 요청 ID가 있어야 합니다")
+	}
+	if !strings.Contains(appRecords.String(), `"msg":"upstream request failed"`) ||
+		!strings.Contains(appRecords.String(), `"request_id":"failing-request"`) {
+		t.Errorf("app 로그에 요청 ID가 없습니다: %s", appRecords.String())
+	}
+	if !strings.Contains(httpRecords.String(), `"request_id":"client-id.42"`) ||
+		!strings.Contains(httpRecords.String(), `"request_id":"failing-request"`) {
+		t.Errorf("access 로그에 요청 ID가 없습니다: %s", httpRecords.String())
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+const (
+	requestIdHeader    = "X-Request-Id"
+	maxRequestIdLength = 128
+)
+
+// requestIdOf returns the client's X-Request-Id when it is safe to log and
+// forward, and a new random id otherwise.
+func requestIdOf(r *http.Request) string {
+	if requestId := r.Header.Get(requestIdHeader); validRequestId(requestId) {
+		return requestId
+	}
+	return newRequestId()
+}
+
+// validRequestId allows ids up to 128 characters of letters, digits and
+// -_.:/+= so uuids, ulids and base64 ids from other proxies pass unchanged.
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		c := requestId[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':' || c == '/' || c == '+' || c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestId returns a random (version 4) uuid.
+func newRequestId() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}